@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"test-task/internal/domain"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func apiKeyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "apikey",
+		Usage: "manage API keys",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "issue a key and print it once",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Required: true, Usage: "who or what the key is for"},
+					&cli.StringFlag{Name: "tenant", Usage: "tenant the key is bound to; without it the key uses the default tenant, or any tenant with the admin scope"},
+					&cli.StringSliceFlag{Name: "scope", Required: true, Usage: "songs:read, songs:write or admin; repeat for several"},
+					&cli.DurationFlag{Name: "expires-in", Usage: "key lifetime, e.g. 720h; without it the key never expires"},
+				},
+				Action: func(c *cli.Context) error {
+					var scopes []domain.Scope
+					for _, s := range c.StringSlice("scope") {
+						for _, part := range strings.Split(s, ",") {
+							scopes = append(scopes, domain.Scope(strings.TrimSpace(part)))
+						}
+					}
+
+					var expiresAt *time.Time
+					if c.IsSet("expires-in") {
+						if c.Duration("expires-in") <= 0 {
+							return fmt.Errorf("--expires-in must be positive")
+						}
+						t := time.Now().UTC().Add(c.Duration("expires-in"))
+						expiresAt = &t
+					}
+
+					a, err := newKeyApp(c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					if tenant := c.String("tenant"); tenant != "" {
+						if _, err := a.tenants.GetTenant(c.Context, tenant); err != nil {
+							return err
+						}
+					}
+
+					token, key, err := a.keyService.CreateKey(c.Context, c.String("name"), c.String("tenant"), scopes, expiresAt)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.ErrWriter, "created key %d (%s), store it now: it cannot be shown again\n", key.ID, key.Prefix)
+					fmt.Fprintln(c.App.Writer, token)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "list keys without their secrets",
+				Action: func(c *cli.Context) error {
+					a, err := newKeyApp(c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					keys, err := a.keyService.ListKeys(c.Context)
+					if err != nil {
+						return err
+					}
+
+					now := time.Now()
+					w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tPREFIX\tNAME\tTENANT\tSCOPES\tEXPIRES\tSTATE")
+					for _, k := range keys {
+						scopes := make([]string, len(k.Scopes))
+						for i, s := range k.Scopes {
+							scopes[i] = string(s)
+						}
+						expires := "never"
+						if k.ExpiresAt != nil {
+							expires = k.ExpiresAt.Local().Format(time.DateTime)
+						}
+						state := "active"
+						switch {
+						case k.RevokedAt != nil:
+							state = "revoked " + k.RevokedAt.Local().Format(time.DateTime)
+						case !k.Active(now):
+							state = "expired"
+						}
+						tenant := k.TenantID
+						if tenant == "" {
+							tenant = "any"
+						}
+						fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Name, tenant, strings.Join(scopes, ","), expires, state)
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "revoke",
+				Usage:     "revoke a key, requests with it are rejected immediately",
+				ArgsUsage: "ID",
+				Action: func(c *cli.Context) error {
+					id, err := strconv.Atoi(c.Args().First())
+					if err != nil || c.NArg() != 1 {
+						return fmt.Errorf("expected a single key ID, see `apikey list`")
+					}
+
+					a, err := newKeyApp(c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					if err := a.keyService.RevokeKey(c.Context, id); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "revoked key %d\n", id)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func newKeyApp(c *cli.Context) (*app, error) {
+	a, err := newApp(c.Context, c)
+	if err != nil {
+		return nil, err
+	}
+	if a.keyService == nil {
+		a.Close()
+		return nil, fmt.Errorf("in-memory storage cannot keep API keys")
+	}
+	return a, nil
+}
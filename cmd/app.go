@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"test-task/internal/auth"
+	"test-task/internal/domain"
+	"test-task/internal/enrichment"
+	"test-task/internal/repository"
+	"test-task/internal/services"
+	"test-task/pkg/config"
+	"test-task/pkg/db"
+
+	"github.com/urfave/cli/v2"
+	"gorm.io/gorm"
+)
+
+// app - зависимости, общие для всех команд. Настройки уже собраны
+// и проверены в loadConfig.
+type app struct {
+	cfg         *config.Config
+	db          *gorm.DB // nil в демо-режиме
+	songService domain.SongService
+	tenants     domain.TenantService
+	keyService  domain.APIKeyService // nil в демо-режиме
+	users       domain.TokenVerifier // nil, если JWT не настроены
+	client      *enrichment.Client
+}
+
+// newApp подключается к хранилищу; ctx прерывает повторные попытки
+// подключения к БД.
+func newApp(ctx context.Context, c *cli.Context) (*app, error) {
+	cfg := appConfig(c)
+	client := enrichment.NewClient(cfg.ExternalAPI)
+
+	var users domain.TokenVerifier
+	if cfg.Auth.Enabled && cfg.Auth.JWTEnabled() {
+		v, err := auth.NewVerifier(cfg.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up user authentication: %w", err)
+		}
+		users = v
+	}
+
+	if cfg.Database.Driver == db.DriverMemory {
+		log.Warn("Using in-memory storage, all data will be lost on exit")
+		tenantRepo := repository.NewMemoryTenantRepo()
+		return &app{
+			cfg:         cfg,
+			songService: newSongService(repository.NewMemorySongRepo(), tenantRepo),
+			tenants:     services.NewTenantService(tenantRepo),
+			users:       users,
+			client:      client,
+		}, nil
+	}
+
+	gdb, err := db.InitDB(ctx, cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	if err := db.InstrumentTracing(gdb); err != nil {
+		return nil, fmt.Errorf("failed to instrument database: %w", err)
+	}
+
+	tenantRepo := repository.NewTenantRepo(gdb)
+	return &app{
+		cfg:         cfg,
+		db:          gdb,
+		songService: newSongService(repository.NewSongRepo(gdb), tenantRepo),
+		tenants:     services.NewTenantService(tenantRepo),
+		keyService:  services.NewAPIKeyService(repository.NewAPIKeyRepo(gdb)),
+		users:       users,
+		client:      client,
+	}, nil
+}
+
+func newSongService(repo domain.SongRepository, tenants domain.TenantRepository) domain.SongService {
+	return services.NewTracedSongService(services.NewSongService(repo, tenants))
+}
+
+// Close закрывает пул соединений с БД, дождавшись начатых запросов.
+func (a *app) Close() {
+	if a.db == nil {
+		return
+	}
+	sqlDB, err := a.db.DB()
+	if err != nil {
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Error("Error closing database: ", err)
+	}
+}
+
+func (a *app) newEnricher(workers int) *enrichment.Enricher {
+	return enrichment.NewEnricher(a.songService, a.tenants, a.client, workers, a.cfg.Enrichment.QueueSize)
+}
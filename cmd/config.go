@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"test-task/pkg/config"
+
+	"github.com/urfave/cli/v2"
+)
+
+const configKey = "config"
+
+// configFlags - флаги для каждой настройки из pkg/config. Они глобальные,
+// поэтому задаются до имени команды: song-library --server-port 9090 serve.
+func configFlags() []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "config", EnvVars: []string{"CONFIG_FILE"}, Usage: "path to a YAML or TOML config file"},
+	}
+	for _, f := range config.Fields() {
+		usage := f.Usage + " (env " + f.Env + ")"
+		flag := &cli.StringFlag{Name: f.Flag, Usage: usage, DefaultText: f.Default}
+		if f.Secret {
+			flag.DefaultText = ""
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// loadConfig собирает настройки один раз до запуска любой команды.
+// Невалидные настройки останавливают приложение сразу.
+func loadConfig(c *cli.Context) error {
+	cfg, err := readConfig(c)
+	if err != nil {
+		return err
+	}
+	c.App.Metadata = map[string]any{configKey: cfg}
+	return nil
+}
+
+// readConfig заново читает файл, окружение и флаги.
+func readConfig(c *cli.Context) (*config.Config, error) {
+	return config.Load(c.String("config"), func(name string) (string, bool) {
+		return c.String(name), c.IsSet(name)
+	})
+}
+
+func appConfig(c *cli.Context) *config.Config {
+	return c.App.Metadata[configKey].(*config.Config)
+}
+
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "print the effective configuration with secrets redacted",
+		Action: func(c *cli.Context) error {
+			fmt.Fprint(c.App.Writer, appConfig(c).String())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"test-task/internal/domain"
+	"test-task/internal/enrichment"
+
+	"github.com/urfave/cli/v2"
+)
+
+func enrichCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "enrich",
+		Usage: "fetch lyrics, release dates and links from the external API",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "all", Usage: "re-enrich every song"},
+			&cli.BoolFlag{Name: "missing", Usage: "enrich only songs without lyrics"},
+			&cli.IntFlag{Name: "workers", Usage: "number of parallel requests to the external API (default enrichment.workers)"},
+			&cli.StringFlag{Name: "tenant", Usage: "enrich only this tenant's songs (default: all tenants)"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("all") == c.Bool("missing") {
+				return fmt.Errorf("specify exactly one of --all or --missing")
+			}
+			if c.IsSet("workers") && c.Int("workers") < 1 {
+				return fmt.Errorf("--workers must be positive")
+			}
+
+			a, err := newApp(c.Context, c)
+			if err != nil {
+				return err
+			}
+			defer a.Close()
+
+			// Каждая песня обогащается от имени своего арендатора, см. Enricher.
+			ctx := domain.WithAllTenants(c.Context)
+			if c.IsSet("tenant") {
+				if ctx, err = tenantContext(c, a); err != nil {
+					return err
+				}
+			}
+
+			workers := a.cfg.Enrichment.Workers
+			if c.IsSet("workers") {
+				workers = c.Int("workers")
+			}
+
+			// Сначала собираем песни, а потом обогащаем: воркеры пишут в БД,
+			// и держать открытый курсор всё это время незачем.
+			var songs []domain.Song
+			filter := domain.SongFilter{WithoutText: c.Bool("missing")}
+			err = a.songService.ExportSongs(ctx, filter, func(s *domain.Song) error {
+				songs = append(songs, *s)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			stats := enrichSongs(ctx, a, songs, workers)
+			fmt.Fprintf(c.App.Writer, "enriched %d of %d songs, %d failed\n", stats.Succeeded, len(songs), stats.Failed)
+			return nil
+		},
+	}
+}
+
+// enrichSongs прогоняет песни через очередь обогащения и ждёт завершения.
+func enrichSongs(ctx context.Context, a *app, songs []domain.Song, workers int) enrichment.Stats {
+	enricher := a.newEnricher(workers)
+	for _, s := range songs {
+		enricher.EnqueueWait(ctx, s)
+	}
+	enricher.Close()
+	return enricher.Stats()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"test-task/internal/domain"
+	"test-task/internal/importer"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+)
+
+func importCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "import",
+		Usage:     "import songs from CSV or JSON files",
+		ArgsUsage: "FILE [FILE...]",
+		Description: "Reads group/song pairs (and optionally lyrics, release dates and links) from files,\n" +
+			"skips rows that already exist in the library and saves the rest.\n" +
+			"Columns are matched by header name, or by 1-based number with --no-header.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "input format: csv or json (default: by file extension)"},
+			&cli.StringFlag{Name: "group-col", Value: "group", Usage: "column (or JSON key) with the group name"},
+			&cli.StringFlag{Name: "song-col", Value: "song", Usage: "column (or JSON key) with the song name"},
+			&cli.StringFlag{Name: "text-col", Value: "text", Usage: "column (or JSON key) with lyrics"},
+			&cli.StringFlag{Name: "release-date-col", Value: "releaseDate", Usage: "column (or JSON key) with the release date"},
+			&cli.StringFlag{Name: "link-col", Value: "link", Usage: "column (or JSON key) with a link to the song"},
+			&cli.StringFlag{Name: "delimiter", Value: ",", Usage: "CSV field delimiter"},
+			&cli.BoolFlag{Name: "no-header", Usage: "CSV file has no header row"},
+			&cli.StringFlag{Name: "date-format", Usage: "Go layout of release dates (RFC 3339, 2006-01-02 and 02.01.2006 are always tried)"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "only report what would be imported"},
+			&cli.BoolFlag{Name: "enrich", Usage: "fetch lyrics from the external API for new songs without text"},
+			tenantFlag(),
+		},
+		Action: runImport,
+	}
+}
+
+func runImport(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("no files to import")
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(c.String("delimiter"))
+	if size == 0 || size != len(c.String("delimiter")) {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+
+	noHeader := c.Bool("no-header")
+	column := func(flag string) string {
+		// Названия колонок по умолчанию не имеют смысла без заголовка.
+		if noHeader && !c.IsSet(flag) {
+			return ""
+		}
+		return c.String(flag)
+	}
+
+	opts := importer.Options{
+		Format: c.String("format"),
+		Mapping: importer.Mapping{
+			Group:       column("group-col"),
+			Song:        column("song-col"),
+			Text:        column("text-col"),
+			ReleaseDate: column("release-date-col"),
+			Link:        column("link-col"),
+		},
+		Delimiter:  delimiter,
+		NoHeader:   noHeader,
+		DateLayout: c.String("date-format"),
+	}
+
+	a, err := newApp(c.Context, c)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	ctx, err := tenantContext(c, a)
+	if err != nil {
+		return err
+	}
+
+	dryRun := c.Bool("dry-run")
+	var enricher domain.SongEnricher
+	if c.Bool("enrich") && !dryRun {
+		e := a.newEnricher(a.cfg.Enrichment.Workers)
+		defer e.Close()
+		enricher = e
+	}
+
+	imp := importer.NewImporter(a.songService, enricher)
+	for _, path := range c.Args().Slice() {
+		report, err := imp.Import(ctx, path, opts, dryRun)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		printReport(c, report)
+	}
+	return nil
+}
+
+func printReport(c *cli.Context, r *importer.Report) {
+	w := c.App.Writer
+	verb := "created"
+	if r.DryRun {
+		verb = "to create"
+	}
+
+	fmt.Fprintf(w, "%s: %d rows, %d %s, %d duplicates, %d invalid, %d skipped\n",
+		r.File, r.Total, r.Created, verb, r.Duplicates, r.Invalid, r.Skipped)
+	if r.Enqueued > 0 {
+		fmt.Fprintf(w, "  %d songs queued for enrichment\n", r.Enqueued)
+	}
+	for _, issue := range r.Issues {
+		fmt.Fprintf(w, "  line %d: %s: %s\n", issue.Line, issue.Status, issue.Reason)
+	}
+}
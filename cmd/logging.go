@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"test-task/pkg/config"
+	"test-task/pkg/logging"
+
+	"github.com/urfave/cli/v2"
+)
+
+const loggingCloseKey = "logging_close"
+
+func setupLogging(c *cli.Context) error {
+	closeLog, err := logging.Setup(loggingOptions(appConfig(c).Logging))
+	if err != nil {
+		return err
+	}
+	c.App.Metadata[loggingCloseKey] = closeLog
+	return nil
+}
+
+// loggingOptions переводит настройки журнала в параметры pkg/logging.
+func loggingOptions(cfg config.Logging) logging.Options {
+	opts := logging.Options{
+		Level:       cfg.Level,
+		JSON:        cfg.Format == config.LogJSON,
+		MaxSizeMB:   cfg.MaxSize,
+		RotateEvery: cfg.RotateEvery,
+		MaxAgeDays:  cfg.MaxAgeDays,
+		MaxBackups:  cfg.MaxBackups,
+		Compress:    cfg.Compress,
+	}
+	for _, output := range cfg.Outputs() {
+		switch output {
+		case config.LogStdout:
+			opts.Stdout = true
+		case config.LogFile:
+			opts.File = cfg.File
+		}
+	}
+	return opts
+}
+
+func closeLogging(c *cli.Context) {
+	closeLog, ok := c.App.Metadata[loggingCloseKey].(func() error)
+	if !ok {
+		return
+	}
+	if err := closeLog(); err != nil {
+		log.Error("Failed to close log file: ", err)
+	}
+}
+
+// reloadLogLevelOnSIGHUP по SIGHUP перечитывает настройки и применяет
+// logging.level без перезапуска. Остальные настройки требуют перезапуска.
+func reloadLogLevelOnSIGHUP(ctx context.Context, c *cli.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				cfg, err := readConfig(c)
+				if err != nil {
+					log.Error("SIGHUP: configuration not reloaded: ", err)
+					continue
+				}
+				if err := logging.SetLevel(cfg.Logging.Level); err != nil {
+					log.Error("SIGHUP: ", err)
+					continue
+				}
+				log.Warnf("SIGHUP: log level set to %s", cfg.Logging.Level)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -1,20 +1,13 @@
 package main
 
 import (
-	"net/http"
 	"os"
-	"strings"
-	"test-task/internal/handlers/song"
-	"test-task/internal/repository"
-	"test-task/internal/services"
-	"test-task/pkg/db"
 	"test-task/pkg/logging"
-	"time"
 
 	_ "test-task/docs"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/urfave/cli/v2"
 )
 
 //	@title			Online song library
@@ -23,59 +16,61 @@ import (
 
 // @host 			localhost:8080
 // @BasePath 		/
+
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				Ключ из `apikey create`. Можно передать и как Authorization: Bearer <ключ>; так же передаётся JWT пользователя от провайдера удостоверений
 var log = logging.GetLogger()
 
 func main() {
-
-	if err := godotenv.Load(); err != nil {
-		log.Warn(".env file not loaded: ", err)
+	serve := serveCommand()
+
+	app := &cli.App{
+		Name:   "song-library",
+		Usage:  "online song library",
+		Before: before,
+		After:  after,
+		// Без команды приложение, как и раньше, запускает сервер.
+		Flags:  append(configFlags(), serve.Flags...),
+		Action: serve.Action,
+		Commands: []*cli.Command{
+			serve,
+			migrateCommand(),
+			seedCommand(),
+			enrichCommand(),
+			importCommand(),
+			routesCommand(),
+			configCommand(),
+			apiKeyCommand(),
+			tenantCommand(),
+		},
 	}
 
-	db, err := db.InitDB()
-	if err != nil {
-		log.Fatal("failed to initialize database: ", err)
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
 	}
-	gin.SetMode(gin.ReleaseMode)
-	r := gin.New()
-	r.Use(gin.Recovery())
-	r.Use(loggerMiddleware())
-
-	song_repository := repository.NewSongRepo(db)
-	song_service := services.NewSongService(song_repository)
-	song_handler := song.NewHandler(song_service)
-	song_handler.Register(r)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	start(r, port)
 }
 
-func start(r *gin.Engine, port string) {
-
-	s := &http.Server{
-		Addr:         ":" + port,
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+func before(c *cli.Context) error {
+	loadEnv()
+	if err := loadConfig(c); err != nil {
+		return err
 	}
-
-	log.Info("Server is running on port: ", port)
-	log.Fatal(s.ListenAndServe())
+	if err := setupLogging(c); err != nil {
+		return err
+	}
+	return setupTracing(c)
 }
 
-func loggerMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		t := time.Now()
-
-		c.Next()
-		method := strings.ToUpper(c.Request.Method)
-		url := c.Request.URL.Path
-		status := c.Writer.Status()
-		latency := time.Since(t)
+func after(c *cli.Context) error {
+	shutdownTracing(c)
+	closeLogging(c)
+	return nil
+}
 
-		log.Infof("[%s]: %s | %d | %.3fms", method, url, status, float64(latency.Microseconds())/1000)
+func loadEnv() {
+	if err := godotenv.Load(); err != nil {
+		log.Warn(".env file not loaded: ", err)
 	}
 }
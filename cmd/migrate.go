@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"test-task/pkg/db"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "manage the database schema",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "up",
+				Usage: "apply all pending migrations",
+				Action: func(c *cli.Context) error {
+					m, err := newMigrator(c)
+					if err != nil {
+						return err
+					}
+					n, err := m.Up(c.Context)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "applied %d migrations\n", n)
+					return nil
+				},
+			},
+			{
+				Name:  "down",
+				Usage: "revert applied migrations (destroys data)",
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "steps", Value: 1, Usage: "number of migrations to revert"},
+					&cli.BoolFlag{Name: "all", Usage: "revert every migration"},
+					&cli.BoolFlag{Name: "yes", Usage: "confirm reverting migrations"},
+				},
+				Action: func(c *cli.Context) error {
+					if !c.Bool("yes") {
+						return fmt.Errorf("migrate down may drop data, rerun with --yes to confirm")
+					}
+					steps := c.Int("steps")
+					if c.Bool("all") {
+						steps = 0
+					} else if steps < 1 {
+						return fmt.Errorf("--steps must be positive")
+					}
+
+					m, err := newMigrator(c)
+					if err != nil {
+						return err
+					}
+					n, err := m.Down(c.Context, steps)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "reverted %d migrations\n", n)
+					return nil
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "list migrations and whether they are applied",
+				Action: func(c *cli.Context) error {
+					m, err := newMigrator(c)
+					if err != nil {
+						return err
+					}
+					statuses, err := m.Status(c.Context)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					for _, s := range statuses {
+						state := "pending"
+						switch {
+						case s.Dirty:
+							state = "DIRTY"
+						case s.Applied:
+							state = "applied " + s.AppliedAt.Local().Format(time.DateTime)
+						}
+						fmt.Fprintf(w, "%06d\t%s\t%s\n", s.Version, s.Name, state)
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "force",
+				Usage:     "mark migrations up to VERSION as applied and clear the dirty flag",
+				ArgsUsage: "VERSION",
+				Action: func(c *cli.Context) error {
+					version, err := strconv.ParseInt(c.Args().First(), 10, 64)
+					if err != nil || c.NArg() != 1 {
+						return fmt.Errorf("expected a single migration version")
+					}
+
+					m, err := newMigrator(c)
+					if err != nil {
+						return err
+					}
+					return m.Force(c.Context, version)
+				},
+			},
+		},
+	}
+}
+
+func newMigrator(c *cli.Context) (*db.Migrator, error) {
+	a, err := newApp(c.Context, c)
+	if err != nil {
+		return nil, err
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("in-memory storage has no schema to migrate")
+	}
+	return db.NewMigrator(a.db)
+}
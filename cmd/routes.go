@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+func routesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "routes",
+		Usage: "print the HTTP route table",
+		Action: func(c *cli.Context) error {
+			// Для таблицы маршрутов зависимости не нужны.
+			r := newRouter(appConfig(c), nil, nil, nil, nil, nil, nil)
+
+			w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+			for _, route := range r.Routes() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", route.Method, route.Path, route.Handler)
+			}
+			return w.Flush()
+		},
+	}
+}
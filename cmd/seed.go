@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Набор песен для локальной разработки и демонстрации.
+//
+//go:embed seed_songs.json
+var seedSongs []byte
+
+type seedSong struct {
+	dto.CreateSongRequest
+	Text string `json:"text"`
+}
+
+func seedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "seed",
+		Usage: "load sample songs into the library (existing songs are skipped)",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "enrich", Usage: "fetch lyrics from the external API for sample songs without text"},
+			tenantFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			a, err := newApp(c.Context, c)
+			if err != nil {
+				return err
+			}
+			defer a.Close()
+
+			ctx, err := tenantContext(c, a)
+			if err != nil {
+				return err
+			}
+
+			created, toEnrich, err := seedSamples(ctx, a)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "seeded %d sample songs\n", created)
+
+			if c.Bool("enrich") && len(toEnrich) > 0 {
+				stats := enrichSongs(ctx, a, toEnrich, 1)
+				fmt.Fprintf(c.App.Writer, "enriched %d songs, %d failed\n", stats.Succeeded, stats.Failed)
+			}
+			return nil
+		},
+	}
+}
+
+// seedSamples сохраняет демонстрационные песни, пропуская уже существующие.
+// Возвращает число созданных песен и те из них, у которых нет текста.
+func seedSamples(ctx context.Context, a *app) (int, []domain.Song, error) {
+	var samples []seedSong
+	if err := json.Unmarshal(seedSongs, &samples); err != nil {
+		return 0, nil, fmt.Errorf("reading sample data: %w", err)
+	}
+
+	songs := make([]*domain.Song, 0, len(samples))
+	for _, s := range samples {
+		songs = append(songs, &domain.Song{
+			Group:       s.Group,
+			Song:        s.Song,
+			Text:        s.Text,
+			ReleaseDate: s.ReleaseDate,
+			Link:        s.Link,
+		})
+	}
+
+	results, err := a.songService.ImportSongs(ctx, songs, domain.ImportOptions{})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	created := 0
+	var withoutText []domain.Song
+	for _, res := range results {
+		if res.Status != domain.ImportCreated {
+			continue
+		}
+		created++
+		if res.Song.Text == "" {
+			withoutText = append(withoutText, *res.Song)
+		}
+	}
+	return created, withoutText, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"test-task/internal/domain"
+	"test-task/internal/enrichment"
+	"test-task/internal/handlers/admin"
+	"test-task/internal/handlers/health"
+	"test-task/internal/handlers/song"
+	"test-task/internal/middleware"
+	"test-task/pkg/config"
+	"test-task/pkg/db"
+	"test-task/pkg/logging"
+	"test-task/pkg/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "start the HTTP server",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "migrate", Usage: "apply migrations before starting"},
+			&cli.BoolFlag{Name: "seed", Usage: "load sample songs before starting (handy with DB_DRIVER=memory)"},
+		},
+		Action: serve,
+	}
+}
+
+func serve(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	reloadLogLevelOnSIGHUP(ctx, c)
+
+	a, err := newApp(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if a.db != nil {
+		if err := checkSchema(c, a); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("seed") {
+		created, _, err := seedSamples(domain.WithTenant(ctx, domain.DefaultTenant), a)
+		if err != nil {
+			return err
+		}
+		log.Infof("Seeded %d sample songs", created)
+	}
+
+	if !a.cfg.Auth.Enabled {
+		log.Warn("Authentication is disabled, every route is open")
+	}
+
+	if a.cfg.ExternalAPI.URL == "" {
+		log.Warn("External API URL is not configured, new songs will not be enriched")
+	}
+
+	if err := registerMetrics(a); err != nil {
+		return err
+	}
+
+	enricher := a.newEnricher(a.cfg.Enrichment.Workers)
+	unqueued := resumeEnrichment(ctx, a, enricher)
+
+	s := newServer(newRouter(a.cfg, a.songService, a.tenants, a.keyService, a.users, enricher, readinessChecks(a)), a.cfg.Server)
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Info("Server is running on port: ", a.cfg.Server.Port)
+		listenErr <- s.ListenAndServe()
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-listenErr:
+	case <-ctx.Done():
+		// Повторный сигнал завершит процесс сразу.
+		stop()
+		log.Infof("Shutting down, waiting up to %s for requests and enrichment", a.cfg.Server.ShutdownTimeout)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Error("HTTP server did not stop in time: ", err)
+	}
+	// Обработчики больше не работают, новых задач не будет.
+	stopEnrichment(shutdownCtx, a, enricher, unqueued)
+
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		return nil
+	}
+	return serveErr
+}
+
+// resumeEnrichment ставит в очередь песни, не обогащённые до прошлой
+// остановки. В checkpoint не больше песен, чем помещается в очередь
+// и воркеры, поэтому ожидание места не задерживает запуск надолго.
+// Возвращает ID, которые поставить не удалось: их сохранит stopEnrichment.
+func resumeEnrichment(ctx context.Context, a *app, enricher *enrichment.Enricher) []int {
+	path := a.cfg.Enrichment.CheckpointFile
+	if path == "" || a.db == nil {
+		return nil
+	}
+
+	ids, err := enrichment.TakeCheckpoint(path)
+	if err != nil {
+		log.Error("Failed to resume enrichment: ", err)
+		return nil
+	}
+
+	// В checkpoint только ID, песни могут принадлежать любым арендаторам.
+	ctx = domain.WithAllTenants(ctx)
+	var unqueued []int
+	resumed := 0
+	for i, id := range ids {
+		song, err := a.songService.GetSong(ctx, id)
+		if errors.Is(err, domain.ErrNotFound) {
+			continue // песню удалили, обогащать нечего
+		}
+		if err != nil {
+			unqueued = append(unqueued, id)
+			continue
+		}
+		if !enricher.EnqueueWait(ctx, *song) {
+			// Процесс уже останавливается.
+			unqueued = append(unqueued, ids[i:]...)
+			break
+		}
+		resumed++
+	}
+	if len(ids) > 0 {
+		log.Infof("Resumed enrichment of %d of %d songs from %s", resumed, len(ids), path)
+	}
+	return unqueued
+}
+
+// stopEnrichment ждёт фоновое обогащение, а то, что не успело
+// выполниться, вместе с unqueued сохраняет в checkpoint-файл или хотя бы
+// в лог.
+func stopEnrichment(ctx context.Context, a *app, enricher *enrichment.Enricher, unqueued []int) {
+	pending := append(enricher.Shutdown(ctx), unqueued...)
+	if len(pending) == 0 {
+		return
+	}
+	sort.Ints(pending)
+
+	path := a.cfg.Enrichment.CheckpointFile
+	if path != "" && a.db != nil {
+		err := enrichment.SaveCheckpoint(path, pending)
+		if err == nil {
+			log.Warnf("Enrichment of %d songs not finished, saved to %s", len(pending), path)
+			return
+		}
+		log.Error(err)
+	}
+	log.Warnf("Enrichment of songs %v not finished, run `enrich --missing` to retry", pending)
+}
+
+func checkSchema(c *cli.Context, a *app) error {
+	migrator, err := db.NewMigrator(a.db)
+	if err != nil {
+		return err
+	}
+	if c.Bool("migrate") {
+		if _, err := migrator.Up(c.Context); err != nil {
+			return err
+		}
+	}
+	// С чужой схемой не работаем: пусть лучше оркестратор увидит падение.
+	if err := migrator.Check(c.Context); err != nil {
+		return fmt.Errorf("refusing to serve: %w (run `migrate up`)", err)
+	}
+	return nil
+}
+
+// Пробы оркестратора дёргают эти пути постоянно, в логе они только мешают.
+var quietPaths = []string{"/healthz", "/readyz", "/metrics"}
+
+func newRouter(cfg *config.Config, songService domain.SongService, tenants domain.TenantService, keyService domain.APIKeyService, users domain.TokenVerifier, enricher domain.SongEnricher, checks []health.Check) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	// Список проверен в config.Validate, ошибки здесь быть не может.
+	_ = r.SetTrustedProxies(cfg.Server.Proxies())
+	r.Use(middleware.Recovery())
+	r.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		return !slices.Contains(quietPaths, req.URL.Path)
+	})))
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Metrics())
+	r.Use(loggerMiddleware(quietPaths...))
+	r.Use(middleware.Errors())
+	r.Use(middleware.SecurityHeaders(cfg.Server.HSTSMaxAge))
+	r.Use(middleware.CORS(cfg.CORS))
+
+	// Пробы и Prometheus ходят без учётных данных, поэтому эти маршруты
+	// регистрируются до проверки доступа: gin добавляет к маршруту только
+	// уже подключённые middleware. Метрики открыты сознательно: в них нет
+	// данных песен, а /metrics принято закрывать на уровне сети.
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	health.NewHandler(checks, cfg.Server.HealthTimeout).Register(r)
+
+	if cfg.Auth.Enabled {
+		r.Use(middleware.Authenticate(keyService, users))
+	} else {
+		r.Use(middleware.AuthDisabled())
+	}
+	r.Use(middleware.Tenant(tenants))
+
+	admin.NewHandler(cfg.API).Register(r)
+
+	song_handler := song.NewHandler(songService, enricher, cfg.API, cfg.RateLimit)
+	song_handler.Register(r)
+
+	return r
+}
+
+func newServer(r *gin.Engine, cfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+// registerMetrics подключает к /metrics запросы к БД, пул соединений
+// и размер каталога.
+func registerMetrics(a *app) error {
+	if a.db != nil {
+		if err := db.InstrumentMetrics(a.db); err != nil {
+			return fmt.Errorf("failed to instrument database: %w", err)
+		}
+		sqlDB, err := a.db.DB()
+		if err != nil {
+			return err
+		}
+		if err := metrics.RegisterDBStats(sqlDB, a.cfg.Database.Driver); err != nil {
+			return err
+		}
+	}
+
+	return metrics.RegisterCatalogue(func(ctx context.Context) (int64, int64, error) {
+		ctx = domain.WithAllTenants(ctx)
+		total, err := a.songService.CountSongs(ctx, domain.SongFilter{})
+		if err != nil {
+			return 0, 0, err
+		}
+		withoutLyrics, err := a.songService.CountSongs(ctx, domain.SongFilter{WithoutText: true})
+		return total, withoutLyrics, err
+	})
+}
+
+// readinessChecks - зависимости, без которых сервис не может отвечать.
+func readinessChecks(a *app) []health.Check {
+	var checks []health.Check
+
+	if a.db != nil {
+		checks = append(checks,
+			health.Check{Name: "database", Critical: true, Run: func(ctx context.Context) error {
+				sqlDB, err := a.db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			}},
+			health.Check{Name: "migrations", Critical: true, Run: func(ctx context.Context) error {
+				migrator, err := db.NewMigrator(a.db)
+				if err != nil {
+					return err
+				}
+				return migrator.Check(ctx)
+			}},
+		)
+	}
+	// С недоступной репликой не работают списки и чтение песен.
+	if a.db != nil && a.cfg.Database.ReplicaDSN != "" {
+		checks = append(checks, health.Check{Name: "database_replica", Critical: true, Run: func(ctx context.Context) error {
+			return db.PingReplica(ctx, a.db)
+		}})
+	}
+
+	// Без внешнего API песни просто не обогащаются, поэтому проверка некритичная.
+	if a.client.Configured() {
+		checks = append(checks, health.Check{Name: "external_api", Run: func(context.Context) error {
+			if a.client.CircuitState() == enrichment.CircuitOpen {
+				return enrichment.ErrCircuitOpen
+			}
+			return nil
+		}})
+	}
+	return checks
+}
+
+func loggerMiddleware(skip ...string) gin.HandlerFunc {
+	quiet := make(map[string]bool, len(skip))
+	for _, path := range skip {
+		quiet[path] = true
+	}
+
+	return func(c *gin.Context) {
+		t := time.Now()
+
+		c.Next()
+		if quiet[c.Request.URL.Path] && c.Writer.Status() < http.StatusInternalServerError {
+			return
+		}
+		method := strings.ToUpper(c.Request.Method)
+		url := c.Request.URL.Path
+		status := c.Writer.Status()
+		latency := time.Since(t)
+
+		logging.FromContext(c.Request.Context()).Infof("[%s]: %s | %d | %.3fms", method, url, status, float64(latency.Microseconds())/1000)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"test-task/internal/domain"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func tenantCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "tenant",
+		Usage: "manage tenants: separate song libraries in one deployment",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "add a tenant",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Required: true, Usage: "display name, e.g. the label"},
+					&cli.IntFlag{Name: "max-songs", Usage: "song quota, 0 - unlimited"},
+					&cli.StringFlag{Name: "lyrics-url", Usage: "tenant's own lyrics provider instead of external_api.url"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expected a single tenant ID")
+					}
+
+					a, err := newApp(c.Context, c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					tenant := &domain.Tenant{
+						ID:        c.Args().First(),
+						Name:      c.String("name"),
+						MaxSongs:  c.Int("max-songs"),
+						LyricsURL: c.String("lyrics-url"),
+					}
+					if err := a.tenants.CreateTenant(c.Context, tenant); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "created tenant %s\n", tenant.ID)
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "list tenants with their quotas and lyrics providers",
+				Action: func(c *cli.Context) error {
+					a, err := newApp(c.Context, c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					tenants, err := a.tenants.ListTenants(c.Context)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tNAME\tSONGS\tMAX SONGS\tLYRICS PROVIDER\tCREATED")
+					for _, t := range tenants {
+						count, err := a.songService.CountSongs(domain.WithTenant(c.Context, t.ID), domain.SongFilter{})
+						if err != nil {
+							return err
+						}
+						quota := "unlimited"
+						if t.MaxSongs > 0 {
+							quota = fmt.Sprint(t.MaxSongs)
+						}
+						provider := "default"
+						if t.LyricsURL != "" {
+							provider = t.LyricsURL
+						}
+						fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", t.ID, t.Name, count, quota, provider, t.CreatedAt.Local().Format(time.DateTime))
+					}
+					return w.Flush()
+				},
+			},
+			{
+				Name:      "update",
+				Usage:     "change a tenant's name, quota or lyrics provider",
+				ArgsUsage: "ID",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "display name"},
+					&cli.IntFlag{Name: "max-songs", Usage: "song quota, 0 - unlimited"},
+					&cli.StringFlag{Name: "lyrics-url", Usage: "lyrics provider, empty - back to external_api.url"},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return fmt.Errorf("expected a single tenant ID")
+					}
+
+					a, err := newApp(c.Context, c)
+					if err != nil {
+						return err
+					}
+					defer a.Close()
+
+					tenant, err := a.tenants.GetTenant(c.Context, c.Args().First())
+					if err != nil {
+						return err
+					}
+					if c.IsSet("name") {
+						tenant.Name = c.String("name")
+					}
+					if c.IsSet("max-songs") {
+						tenant.MaxSongs = c.Int("max-songs")
+					}
+					if c.IsSet("lyrics-url") {
+						tenant.LyricsURL = c.String("lyrics-url")
+					}
+					if err := a.tenants.UpdateTenant(c.Context, tenant); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "updated tenant %s\n", tenant.ID)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// tenantFlag выбирает арендатора для команд, которые работают с песнями.
+func tenantFlag() cli.Flag {
+	return &cli.StringFlag{Name: "tenant", Value: domain.DefaultTenant, Usage: "tenant whose library to work with"}
+}
+
+// tenantContext проверяет арендатора из --tenant и ограничивает им ctx.
+func tenantContext(c *cli.Context, a *app) (context.Context, error) {
+	id := c.String("tenant")
+	if _, err := a.tenants.GetTenant(c.Context, id); err != nil {
+		return nil, err
+	}
+	return domain.WithTenant(c.Context, id), nil
+}
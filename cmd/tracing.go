@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"test-task/pkg/tracing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const tracingShutdownKey = "tracing_shutdown"
+
+// Сколько ждать отправки последних span-ов при выходе.
+const tracingFlushTimeout = 5 * time.Second
+
+func setupTracing(c *cli.Context) error {
+	shutdown, err := tracing.Setup(c.Context, appConfig(c).Tracing)
+	if err != nil {
+		return err
+	}
+	c.App.Metadata[tracingShutdownKey] = shutdown
+	return nil
+}
+
+func shutdownTracing(c *cli.Context) {
+	shutdown, ok := c.App.Metadata[tracingShutdownKey].(func(context.Context) error)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Error("Failed to flush traces: ", err)
+	}
+}
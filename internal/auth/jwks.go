@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/singleflight"
+)
+
+// Незнакомый kid обычно значит, что провайдер сменил ключи. Но и поддельный
+// токен может назвать любой kid, поэтому внеочередная загрузка не чаще этого.
+const minJWKSRefetch = 30 * time.Second
+
+const maxJWKSSize = 1 << 20
+
+// keySet - ключи подписи провайдера. Из файла читаются один раз, по URL -
+// перечитываются раз в refresh и при незнакомом kid.
+//
+// Загрузка идёт без блокировок: проверка токенов читает текущий снимок
+// ключей, а новый снимок подменяет его целиком. Одновременные загрузки
+// склеиваются в одну через singleflight.
+type keySet struct {
+	url     string
+	refresh time.Duration
+	http    *http.Client
+
+	current atomic.Pointer[jwksSnapshot]
+	fetches singleflight.Group
+}
+
+// jwksSnapshot не меняется после публикации.
+type jwksSnapshot struct {
+	keys jose.JSONWebKeySet
+	// fetchedAt - время последней попытки загрузки, в том числе неудачной.
+	fetchedAt time.Time
+}
+
+func newFileKeySet(path string) (*keySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("JWKS file %s: %w", path, err)
+	}
+	s := &keySet{}
+	s.current.Store(&jwksSnapshot{keys: keys})
+	return s, nil
+}
+
+func newURLKeySet(url string, refresh time.Duration) *keySet {
+	s := &keySet{
+		url:     url,
+		refresh: refresh,
+		http:    &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+	s.current.Store(&jwksSnapshot{})
+	return s
+}
+
+// key возвращает ключ с идентификатором kid. Провайдер может быть недоступен:
+// тогда работаем со старыми ключами, пока они есть.
+func (s *keySet) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	snap := s.current.Load()
+
+	if s.url != "" {
+		stale := time.Since(snap.fetchedAt) > s.refresh
+		unknown := len(snap.keys.Key(kid)) == 0 && time.Since(snap.fetchedAt) > minJWKSRefetch
+		if stale || unknown {
+			var err error
+			if snap, err = s.refetch(ctx); err != nil && len(snap.keys.Keys) == 0 {
+				return nil, err
+			}
+		}
+	}
+
+	keys := snap.keys.Key(kid)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return &keys[0], nil
+}
+
+// refetch загружает JWKS, присоединяясь к уже идущей загрузке, если она
+// есть. Возвращает снимок после загрузки; при ошибке в нём старые ключи.
+func (s *keySet) refetch(ctx context.Context) (*jwksSnapshot, error) {
+	// Загрузку делят все ждущие её запросы, поэтому отмена одного из них
+	// её не прерывает: её ограничивает таймаут http-клиента.
+	ch := s.fetches.DoChan("jwks", func() (any, error) {
+		return nil, s.fetch(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-ch:
+		return s.current.Load(), res.Err
+	case <-ctx.Done():
+		return s.current.Load(), ctx.Err()
+	}
+}
+
+// fetch загружает JWKS по URL и публикует новый снимок.
+func (s *keySet) fetch(ctx context.Context) error {
+	// Даже неудачная попытка откладывает следующую: упавший провайдер
+	// не должен получать запрос на каждый входящий токен.
+	attempt := time.Now()
+	keys, err := s.download(ctx)
+	if err != nil {
+		keys = s.current.Load().keys
+	}
+	s.current.Store(&jwksSnapshot{keys: keys, fetchedAt: attempt})
+	return err
+}
+
+func (s *keySet) download(ctx context.Context) (jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return jose.JSONWebKeySet{}, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return jose.JSONWebKeySet{}, fmt.Errorf("JWKS from %s: %w", s.url, err)
+	}
+	return keys, nil
+}
+
+func parseJWKS(data []byte) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return keys, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	for _, k := range keys.Keys {
+		if !k.IsPublic() {
+			return keys, fmt.Errorf("key %q is private, JWKS must contain only public keys", k.KeyID)
+		}
+	}
+	if len(keys.Keys) == 0 {
+		return keys, fmt.Errorf("JWKS has no keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer отдаёт testdata/jwks.json, пока не закрыт release,
+// и считает запросы.
+func jwksServer(t *testing.T, release <-chan struct{}, hits *atomic.Int32, fail *atomic.Bool) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile("testdata/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestKeySetFetchesOnceForConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	var hits atomic.Int32
+	var fail atomic.Bool
+	s := newURLKeySet(jwksServer(t, release, &hits, &fail).URL, time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.key(context.Background(), "test-key")
+			errs <- err
+		}()
+	}
+
+	// Пока загрузка висит, запрос со своим дедлайном не ждёт её дольше него.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.key(ctx, "test-key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting caller: err = %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestKeySetKeepsKeysWhenProviderFails(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var hits atomic.Int32
+	var fail atomic.Bool
+	s := newURLKeySet(jwksServer(t, release, &hits, &fail).URL, time.Hour)
+
+	if _, err := s.key(context.Background(), "test-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ключи устарели, а провайдер лежит: работаем со старыми.
+	fail.Store(true)
+	snap := *s.current.Load()
+	snap.fetchedAt = time.Now().Add(-2 * time.Hour)
+	s.current.Store(&snap)
+
+	if _, err := s.key(context.Background(), "test-key"); err != nil {
+		t.Errorf("stale keys: err = %v, want the cached key", err)
+	}
+	if _, err := s.key(context.Background(), "other-key"); err == nil {
+		t.Error("unknown kid right after a failed fetch: want an error")
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2: a failed fetch must delay the next one", n)
+	}
+}
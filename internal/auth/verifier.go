@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/config"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Только асимметричные подписи: с HMAC подписать токен мог бы любой,
+// кто видел JWKS.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Verifier проверяет JWT пользователей: подпись по JWKS провайдера,
+// срок действия, iss и aud, и переводит роли из claims в права.
+type Verifier struct {
+	keys        *keySet
+	issuer      string
+	audience    string
+	rolesClaim  []string
+	tenantClaim []string
+	roleMap     map[string]domain.Role
+	leeway      time.Duration
+	now         func() time.Time
+}
+
+func NewVerifier(cfg config.Auth) (*Verifier, error) {
+	v := &Verifier{
+		issuer:      cfg.Issuer,
+		audience:    cfg.Audience,
+		rolesClaim:  strings.Split(cfg.RolesClaim, "."),
+		tenantClaim: strings.Split(cfg.TenantClaim, "."),
+		roleMap:     map[string]domain.Role{},
+		leeway:      cfg.Leeway,
+		now:         time.Now,
+	}
+	for from, to := range cfg.RoleMapping() {
+		role := domain.Role(to)
+		if _, ok := domain.RoleScopes[role]; !ok {
+			return nil, fmt.Errorf("auth.role_map: unknown role %q, use %s, %s or %s", to, domain.RoleViewer, domain.RoleEditor, domain.RoleAdmin)
+		}
+		v.roleMap[from] = role
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := newFileKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	} else {
+		v.keys = newURLKeySet(cfg.JWKSURL, cfg.JWKSRefresh)
+	}
+	return v, nil
+}
+
+// Verify проверяет токен. Любая ошибка оборачивает domain.ErrUnauthorized,
+// чтобы клиент получил 401 с причиной отказа.
+func (v *Verifier) Verify(ctx context.Context, token string) (*domain.Principal, error) {
+	principal, err := v.verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid token: %v", domain.ErrUnauthorized, err)
+	}
+	return principal, nil
+}
+
+func (v *Verifier) verify(ctx context.Context, token string) (*domain.Principal, error) {
+	tok, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("expected a single signature")
+	}
+	key, err := v.keys.key(ctx, tok.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		claims jwt.Claims
+		custom map[string]any
+	)
+	if err := tok.Claims(key, &claims, &custom); err != nil {
+		return nil, err
+	}
+
+	// Бессрочный токен не отозвать, такие не принимаем.
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("token has no exp claim")
+	}
+	// iss и aud обязательны в конфиге, поэтому проверяются всегда.
+	expected := jwt.Expected{Issuer: v.issuer, AnyAudience: jwt.Audience{v.audience}, Time: v.now()}
+	if err := claims.ValidateWithLeeway(expected, v.leeway); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("token has no sub claim")
+	}
+
+	principal := &domain.Principal{Subject: claims.Subject}
+	if tenant, ok := claimAt(custom, v.tenantClaim).(string); ok {
+		principal.Tenant = tenant
+	}
+	for _, name := range roleNames(claimAt(custom, v.rolesClaim)) {
+		role := domain.Role(name)
+		if mapped, ok := v.roleMap[name]; ok {
+			role = mapped
+		}
+		scopes, ok := domain.RoleScopes[role]
+		if !ok {
+			continue
+		}
+		principal.Roles = append(principal.Roles, role)
+		principal.Scopes = append(principal.Scopes, scopes...)
+	}
+	return principal, nil
+}
+
+// claimAt достаёт claim по пути: realm_access.roles - это поле roles
+// объекта realm_access.
+func claimAt(claims map[string]any, path []string) any {
+	var value any = claims
+	for _, part := range path {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+	return value
+}
+
+// roleNames разбирает роли: массив строк или строка через пробел,
+// как scope в OAuth.
+func roleNames(value any) []string {
+	switch roles := value.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []any:
+		var names []string
+		for _, r := range roles {
+			if name, ok := r.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
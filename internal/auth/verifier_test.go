@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"test-task/internal/domain"
+	"test-task/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	testIssuer   = "https://idp.test"
+	testAudience = "songs"
+)
+
+// testdata/jwks.json - открытая часть testdata/signing_key.json.
+func signingKey(t *testing.T) jose.JSONWebKey {
+	t.Helper()
+	data, err := os.ReadFile("testdata/signing_key.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key jose.JSONWebKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	cfg := config.Default().Auth
+	cfg.JWKSFile = "testdata/jwks.json"
+	cfg.Issuer = testIssuer
+	cfg.Audience = testAudience
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func validClaims() (jwt.Claims, map[string]any) {
+	now := time.Now()
+	return jwt.Claims{
+		Issuer:   testIssuer,
+		Audience: jwt.Audience{testAudience},
+		Subject:  "alice",
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}, map[string]any{
+		"roles":  []string{"editor"},
+		"tenant": "label-a",
+	}
+}
+
+func sign(t *testing.T, alg jose.SignatureAlgorithm, key any, kid string, claims jwt.Claims, custom map[string]any) string {
+	t.Helper()
+	opts := (&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), kid)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Claims(custom).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyValidToken(t *testing.T) {
+	v := newTestVerifier(t)
+	key := signingKey(t)
+
+	claims, custom := validClaims()
+	principal, err := v.Verify(context.Background(), sign(t, jose.ES256, key.Key, key.KeyID, claims, custom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "alice" || principal.Tenant != "label-a" {
+		t.Errorf("principal = %+v, want alice from label-a", principal)
+	}
+	if !slices.Equal(principal.Roles, []domain.Role{domain.RoleEditor}) || !principal.HasScope(domain.ScopeSongsWrite) {
+		t.Errorf("roles = %v, scopes = %v, want editor", principal.Roles, principal.Scopes)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	v := newTestVerifier(t)
+	key := signingKey(t)
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"expired", func() string {
+			claims, custom := validClaims()
+			claims.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+			return sign(t, jose.ES256, key.Key, key.KeyID, claims, custom)
+		}},
+		{"wrong audience", func() string {
+			claims, custom := validClaims()
+			claims.Audience = jwt.Audience{"billing"}
+			return sign(t, jose.ES256, key.Key, key.KeyID, claims, custom)
+		}},
+		{"no audience", func() string {
+			claims, custom := validClaims()
+			claims.Audience = nil
+			return sign(t, jose.ES256, key.Key, key.KeyID, claims, custom)
+		}},
+		{"wrong issuer", func() string {
+			claims, custom := validClaims()
+			claims.Issuer = "https://evil.test"
+			return sign(t, jose.ES256, key.Key, key.KeyID, claims, custom)
+		}},
+		{"no expiry", func() string {
+			claims, custom := validClaims()
+			claims.Expiry = nil
+			return sign(t, jose.ES256, key.Key, key.KeyID, claims, custom)
+		}},
+		{"hmac", func() string {
+			// Секрет - открытый ключ из JWKS: его знает любой.
+			pub, _ := json.Marshal(key.Public())
+			claims, custom := validClaims()
+			return sign(t, jose.HS256, pub, key.KeyID, claims, custom)
+		}},
+		{"unknown kid", func() string {
+			claims, custom := validClaims()
+			return sign(t, jose.ES256, key.Key, "other-key", claims, custom)
+		}},
+		{"garbage", func() string { return "not.a.token" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token())
+			if !errors.Is(err, domain.ErrUnauthorized) {
+				t.Errorf("err = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
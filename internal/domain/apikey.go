@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Scope - право, выданное API-ключу.
+type Scope string
+
+const (
+	ScopeSongsRead  Scope = "songs:read"
+	ScopeSongsWrite Scope = "songs:write"
+	// admin включает все остальные права.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes - все известные права.
+var Scopes = []Scope{ScopeSongsRead, ScopeSongsWrite, ScopeAdmin}
+
+// APIKey - ключ доступа к API. Сам ключ не хранится, только его хеш;
+// по Prefix ключ находят при проверке и отличают в списке.
+type APIKey struct {
+	ID        int
+	Name      string
+	TenantID  string // "" - ключ не привязан к арендатору
+	Prefix    string
+	Hash      string
+	Scopes    []Scope
+	CreatedAt time.Time
+	ExpiresAt *time.Time // nil - бессрочный
+	RevokedAt *time.Time
+}
+
+// HasScope сообщает, есть ли у ключа право scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// Principal представляет ключ как вызывающего запроса.
+func (k *APIKey) Principal() *Principal {
+	return &Principal{Subject: "apikey:" + k.Prefix, Tenant: k.TenantID, Scopes: k.Scopes}
+}
+
+// Active - ключ не отозван и не истёк к моменту now.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Интерфейс сервиса API-ключей
+type APIKeyService interface {
+	// CreateKey выпускает ключ и возвращает его открытое значение:
+	// показать его можно только сейчас, в БД остаётся лишь хеш.
+	// tenant "" выпускает ключ, не привязанный к арендатору.
+	CreateKey(ctx context.Context, name, tenant string, scopes []Scope, expiresAt *time.Time) (string, *APIKey, error)
+	Authenticate(ctx context.Context, token string) (*APIKey, error)
+	ListKeys(ctx context.Context) ([]APIKey, error)
+	RevokeKey(ctx context.Context, id int) error
+}
+
+// Интерфейс репозитория API-ключей
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	// Revoke отзывает действующий ключ; ErrNotFound, если такого нет.
+	Revoke(ctx context.Context, id int, at time.Time) error
+}
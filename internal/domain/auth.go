@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// Role - роль пользователя, вошедшего через провайдера удостоверений.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// RoleScopes - права, которые даёт роль. Маршруты проверяют права,
+// поэтому пользователи и API-ключи проходят одни и те же проверки.
+var RoleScopes = map[Role][]Scope{
+	RoleViewer: {ScopeSongsRead},
+	RoleEditor: {ScopeSongsRead, ScopeSongsWrite},
+	RoleAdmin:  {ScopeAdmin},
+}
+
+// Principal - тот, от чьего имени выполняется запрос: API-ключ
+// или пользователь с JWT.
+type Principal struct {
+	// Subject попадает в created_by/updated_by песен: sub из JWT
+	// или apikey:<префикс> для ключа.
+	Subject string
+	// Tenant - арендатор, к которому привязан вызывающий; "" - не привязан
+	// и работает с арендатором по умолчанию или, с правом admin, с любым.
+	Tenant string
+	Roles  []Role // только у пользователей
+	Scopes []Scope
+}
+
+// HasScope сообщает, есть ли у вызывающего право scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// Интерфейс проверки пользовательских токенов
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+type actorKey struct{}
+
+// WithActor кладёт в контекст того, кто меняет данные.
+func WithActor(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, actorKey{}, subject)
+}
+
+// ActorFromContext возвращает автора изменений или "", если он неизвестен
+// (проверка доступа выключена, фоновые задачи, CLI).
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "errors"
+
+// Виды ошибок предметной области. Репозиторий и сервисы оборачивают
+// их через %w, а HTTP-слой по ним выбирает код ответа.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrInvalidInput = errors.New("invalid input")
+	// Тело запроса больше допустимого.
+	ErrTooLarge    = errors.New("request body too large")
+	ErrUnavailable = errors.New("service unavailable")
+	// Нет учётных данных или они недействительны.
+	ErrUnauthorized = errors.New("unauthorized")
+	// Учётные данные действительны, но прав не хватает.
+	ErrForbidden = errors.New("forbidden")
+	// Арендатор исчерпал свою квоту.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// Клиент присылает запросы чаще, чем разрешено.
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
@@ -1,34 +1,105 @@
 package domain
 
 import (
+	"context"
 	"time"
 )
 
-// Модель песни в БД
+// Песня. Как она хранится, решает репозиторий, как выглядит в API -
+// DTO обработчиков; сама модель ни от того, ни от другого не зависит.
 type Song struct {
-	ID          int       `gorm:"primaryKey;autoIncrement" json:"song_id"`
-	Group       string    `gorm:"type:varchar(100);not null" json:"group"`
-	Song        string    `gorm:"type:varchar(100);not null" json:"song"`
-	Text        string    `gorm:"type:text" json:"text"`
-	ReleaseDate time.Time `json:"release_date,omitempty"`
-	Link        string    `gorm:"type:varchar(255)" json:"link"`
+	ID          int
+	TenantID    string
+	Group       string
+	Song        string
+	Text        string
+	ReleaseDate time.Time
+	Link        string
+	// Subject того, кто добавил и кто последним изменил песню.
+	CreatedBy string
+	UpdatedBy string
+}
+
+// Данные песни из внешнего API
+type SongInfo struct {
+	Text        string
+	ReleaseDate time.Time
+	Link        string
+}
+
+// Фильтр для выборки песен
+type SongFilter struct {
+	Group       string
+	Song        string
+	WithoutText bool // только песни без текста
+}
+
+// Ключ, по которому песни считаются одинаковыми: группа + название
+type SongKey struct {
+	Group string
+	Song  string
+}
+
+func (s *Song) Key() SongKey {
+	return SongKey{Group: s.Group, Song: s.Song}
+}
+
+// Итог импорта одной песни при массовой загрузке
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportDuplicate ImportStatus = "duplicate"
+	ImportInvalid   ImportStatus = "invalid"
+	ImportSkipped   ImportStatus = "skipped"
+)
+
+type ImportOptions struct {
+	Atomic bool // сохранить всё или ничего
+	DryRun bool // только проверить, ничего не сохраняя
+}
+
+type ImportResult struct {
+	Status ImportStatus
+	Song   *Song
+	Reason string
 }
 
 // Интерфейс сервиса для бизнес-логики песен
 type SongService interface {
-	GetSongs(group_name, song_name string, page, limit int) ([]Song, error)
-	GetTextBySongID(id, page, limit int) ([]string, error)
-	DeleteSong(id int) error
-	UpdateSong(id int, upd_song *Song) (*Song, error)
-	CreateSong(song *Song) error
-	UpdateSongInfo(song *Song, data interface{}) error
+	GetSongs(ctx context.Context, group_name, song_name string, page, limit int) ([]Song, error)
+	GetSong(ctx context.Context, id int) (*Song, error)
+	GetTextBySongID(ctx context.Context, id, page, limit int) ([]string, error)
+	DeleteSong(ctx context.Context, id int) error
+	UpdateSong(ctx context.Context, id int, upd_song *Song) (*Song, error)
+	CreateSong(ctx context.Context, song *Song) error
+	UpdateSongInfo(ctx context.Context, song *Song, info SongInfo) error
+	ImportSongs(ctx context.Context, songs []*Song, opts ImportOptions) ([]ImportResult, error)
+	ExportSongs(ctx context.Context, filter SongFilter, fn func(*Song) error) error
+	CountSongs(ctx context.Context, filter SongFilter) (int64, error)
 }
 
 // Интерфейс репозитория для работы с песнями
 type SongRepository interface {
-	GetAll(group, song string, offset, limit int) ([]Song, error)
-	GetByID(id int) (*Song, error)
-	Delete(id int) error
-	Update(song *Song) error
-	Create(song *Song) error
+	GetAll(ctx context.Context, group, song string, offset, limit int) ([]Song, error)
+	GetByID(ctx context.Context, id int) (*Song, error)
+	Delete(ctx context.Context, id int) error
+	// UpdateTitle меняет группу и название (пустые не трогает) и автора
+	// изменения, затем заполняет song сохранёнными данными.
+	UpdateTitle(ctx context.Context, song *Song) error
+	// UpdateInfo сохраняет данные из внешнего API: текст, дату выхода и ссылку.
+	UpdateInfo(ctx context.Context, song *Song) error
+	Create(ctx context.Context, song *Song) error
+	CreateBatch(ctx context.Context, songs []*Song) error
+	ExistingKeys(ctx context.Context, keys []SongKey) (map[SongKey]int, error)
+	Stream(ctx context.Context, filter SongFilter, fn func(*Song) error) error
+	Count(ctx context.Context, filter SongFilter) (int64, error)
+}
+
+// Интерфейс очереди обогащения песен данными из внешнего API
+type SongEnricher interface {
+	// Enqueue не ждёт: при заполненной очереди песня пропускается.
+	Enqueue(ctx context.Context, song Song) bool
+	// EnqueueWait ждёт места в очереди, пока не отменён ctx.
+	EnqueueWait(ctx context.Context, song Song) bool
 }
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTenant - арендатор, которому принадлежат данные, созданные до
+// разделения библиотек, и запросы, не выбравшие арендатора.
+const DefaultTenant = "default"
+
+// Tenant - библиотека одного лейбла. Песни и API-ключи принадлежат
+// арендатору, запросы одного арендатора не видят данных другого.
+type Tenant struct {
+	ID        string
+	Name      string
+	MaxSongs  int    // 0 - без ограничения
+	LyricsURL string // "" - общий external_api.url
+	CreatedAt time.Time
+}
+
+// Интерфейс сервиса арендаторов
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant *Tenant) error
+	GetTenant(ctx context.Context, id string) (*Tenant, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+	UpdateTenant(ctx context.Context, tenant *Tenant) error
+}
+
+// Интерфейс репозитория арендаторов
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *Tenant) error
+	GetByID(ctx context.Context, id string) (*Tenant, error)
+	List(ctx context.Context) ([]Tenant, error)
+	Update(ctx context.Context, tenant *Tenant) error
+}
+
+type tenantKey struct{}
+
+type tenantScope struct {
+	id  string
+	all bool
+}
+
+// WithTenant ограничивает работу с песнями в ctx арендатором id.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{id: id})
+}
+
+// WithAllTenants открывает чтение песен всех арендаторов. Только для
+// системных задач: метрик каталога, возобновления и повторного обогащения.
+// Записывать песни в таком контексте нельзя.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantScope{all: true})
+}
+
+// TenantFromContext возвращает арендатора из ctx. ok равен false, если
+// арендатор не выбран или ctx открыт для всех арендаторов.
+func TenantFromContext(ctx context.Context) (id string, ok bool) {
+	scope, _ := ctx.Value(tenantKey{}).(tenantScope)
+	return scope.id, scope.id != ""
+}
+
+// AllTenants сообщает, открыт ли ctx для всех арендаторов.
+func AllTenants(ctx context.Context) bool {
+	scope, _ := ctx.Value(tenantKey{}).(tenantScope)
+	return scope.all
+}
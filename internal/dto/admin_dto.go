@@ -0,0 +1,6 @@
+package dto
+
+// Уровень журнала: trace, debug, info, warn или error.
+type LogLevel struct {
+	Level string `json:"level" binding:"required,oneof=trace debug info warn error" example:"debug"`
+}
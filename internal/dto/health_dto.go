@@ -0,0 +1,22 @@
+package dto
+
+// Статусы проверок здоровья.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // некритичная зависимость недоступна
+	HealthFail     = "fail"
+)
+
+// Результат проверки одной зависимости.
+type HealthCheck struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Ответ /healthz и /readyz.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
@@ -1,19 +1,49 @@
 package dto
 
-import "time"
+import (
+	"strings"
+	"test-task/internal/validation"
+	"time"
+)
 
-type SongRequest struct {
-	Group string `json:"group" binding:"required"`
-	Song  string `json:"song" binding:"required"`
+// JSON-поля всех DTO называются в camelCase: id, releaseDate, createdBy.
+
+// Данные для изменения песни: меняются только группа и название.
+type UpdateSongRequest struct {
+	Group string `json:"group" binding:"required,max=100"`
+	Song  string `json:"song" binding:"required,max=100"`
+}
+
+func (r *UpdateSongRequest) Normalize() {
+	r.Group = strings.TrimSpace(r.Group)
+	r.Song = strings.TrimSpace(r.Song)
+}
+
+// Данные для создания песни. ID и текст клиент задать не может:
+// ID выдаёт БД, текст подтягивается из внешнего API.
+type CreateSongRequest struct {
+	Group       string    `json:"group" binding:"required,max=100"`
+	Song        string    `json:"song" binding:"required,max=100"`
+	ReleaseDate time.Time `json:"releaseDate,omitempty" binding:"releasedate"`
+	Link        string    `json:"link,omitempty" binding:"omitempty,max=255,http_url"`
+}
+
+func (r *CreateSongRequest) Normalize() {
+	r.Group = strings.TrimSpace(r.Group)
+	r.Song = strings.TrimSpace(r.Song)
+	r.Link = strings.TrimSpace(r.Link)
 }
 
-type Song struct {
-	ID          int       `json:"id"`
-	Group       string    `json:"group"`
-	Song        string    `json:"song"`
-	Text        string    `json:"text,omitempty"`
-	ReleaseDate time.Time `json:"releaseDate,omitempty"`
-	Link        string    `json:"link,omitempty"`
+// Песня в ответах API. Неизвестная дата выхода - null, как в выгрузке.
+type SongResponse struct {
+	ID          int        `json:"id"`
+	Group       string     `json:"group"`
+	Song        string     `json:"song"`
+	Text        string     `json:"text,omitempty"`
+	ReleaseDate *time.Time `json:"releaseDate" extensions:"x-nullable"`
+	Link        string     `json:"link,omitempty"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	UpdatedBy   string     `json:"updatedBy,omitempty"`
 }
 
 type ExternalAPIResponse struct {
@@ -22,8 +52,15 @@ type ExternalAPIResponse struct {
 	Link        string    `json:"link"`
 }
 
-type ResponseError struct {
-	Error string `json:"error"`
+// Описание ошибки в формате RFC 7807 (application/problem+json).
+type Problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	RequestID string                  `json:"requestId,omitempty"`
+	Errors    []validation.FieldError `json:"errors,omitempty"`
 }
 
 type ResponseMessage struct {
@@ -31,6 +68,26 @@ type ResponseMessage struct {
 }
 
 type ResponseMessageWithData struct {
-	Message string `json:"message"`
-	Result  Song   `json:"result,omitempty"`
+	Message string        `json:"message"`
+	Result  *SongResponse `json:"result,omitempty"`
+}
+
+// Результат импорта одной песни из массовой загрузки
+type BulkItemResult struct {
+	Index  int                     `json:"index"`
+	Status string                  `json:"status"`
+	ID     int                     `json:"id,omitempty"`
+	Reason string                  `json:"reason,omitempty"`
+	Errors []validation.FieldError `json:"errors,omitempty"`
+}
+
+type BulkResponse struct {
+	Atomic     bool             `json:"atomic"`
+	Committed  bool             `json:"committed"`
+	Created    int              `json:"created"`
+	Duplicates int              `json:"duplicates"`
+	Invalid    int              `json:"invalid"`
+	Skipped    int              `json:"skipped"`
+	Enqueued   int              `json:"enqueued"` // созданных песен поставлено в очередь на обогащение
+	Results    []BulkItemResult `json:"results"`
 }
@@ -0,0 +1,67 @@
+package dto
+
+import "test-task/internal/domain"
+
+// Преобразования между DTO и domain. Обработчики не собирают структуры
+// вручную, чтобы поле, добавленное в одну из сторон, не потерялось
+// в одном из ответов.
+
+// ToDomain - новая песня из запроса. ID, текст и арендатора
+// заполняют репозиторий и обогащение.
+func (r *CreateSongRequest) ToDomain() *domain.Song {
+	return &domain.Song{
+		Group:       r.Group,
+		Song:        r.Song,
+		ReleaseDate: r.ReleaseDate,
+		Link:        r.Link,
+	}
+}
+
+// ToDomain - изменения песни из запроса.
+func (r *UpdateSongRequest) ToDomain() *domain.Song {
+	return &domain.Song{
+		Group: r.Group,
+		Song:  r.Song,
+	}
+}
+
+// ToDomain - данные песни из ответа внешнего API.
+func (r *ExternalAPIResponse) ToDomain() domain.SongInfo {
+	return domain.SongInfo{
+		Text:        r.Text,
+		ReleaseDate: r.ReleaseDate,
+		Link:        r.Link,
+	}
+}
+
+func NewSongResponse(s *domain.Song) SongResponse {
+	resp := SongResponse{
+		ID:        s.ID,
+		Group:     s.Group,
+		Song:      s.Song,
+		Text:      s.Text,
+		Link:      s.Link,
+		CreatedBy: s.CreatedBy,
+		UpdatedBy: s.UpdatedBy,
+	}
+	if !s.ReleaseDate.IsZero() {
+		date := s.ReleaseDate
+		resp.ReleaseDate = &date
+	}
+	return resp
+}
+
+// NewSongMessage - сообщение об изменении песни вместе с ней самой.
+func NewSongMessage(message string, s *domain.Song) ResponseMessageWithData {
+	song := NewSongResponse(s)
+	return ResponseMessageWithData{Message: message, Result: &song}
+}
+
+// NewSongsResponse - список песен; пустой список остаётся [], а не null.
+func NewSongsResponse(songs []domain.Song) []SongResponse {
+	resp := make([]SongResponse, len(songs))
+	for i := range songs {
+		resp[i] = NewSongResponse(&songs[i])
+	}
+	return resp
+}
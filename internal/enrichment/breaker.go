@@ -0,0 +1,94 @@
+package enrichment
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen - внешний API подряд отвечал ошибками, запросы
+// временно не отправляются.
+var ErrCircuitOpen = errors.New("external API circuit is open")
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// breaker - простой предохранитель: после threshold ошибок подряд
+// запросы блокируются на cooldown, затем пропускается один пробный.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow сообщает, можно ли сейчас обращаться к API.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case CircuitClosed:
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// record учитывает результат запроса; failed - API недоступен или сломан.
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// release освобождает пробный запрос, не давший ответа (его отменили):
+// о доступности API он ничего не говорит.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *breaker) state() string {
+	if b.failures < b.threshold {
+		return CircuitClosed
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
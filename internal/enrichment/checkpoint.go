@@ -0,0 +1,42 @@
+package enrichment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SaveCheckpoint сохраняет ID песен, которые не успели обогатиться
+// до остановки, чтобы следующий запуск поставил их в очередь заново.
+func SaveCheckpoint(path string, ids []int) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write enrichment checkpoint: %w", err)
+	}
+	return nil
+}
+
+// TakeCheckpoint читает сохранённые ID и удаляет файл.
+// Если файла нет, возвращает пустой список.
+func TakeCheckpoint(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enrichment checkpoint: %w", err)
+	}
+
+	var ids []int
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("invalid enrichment checkpoint %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("failed to remove enrichment checkpoint: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,179 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/pkg/config"
+	"test-task/pkg/metrics"
+	"test-task/pkg/ratelimit"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Client запрашивает данные о песне (текст, дату релиза, ссылку) во внешнем API.
+// У арендатора может быть свой провайдер; у каждого адреса свой
+// предохранитель, чтобы сбой одного провайдера не останавливал остальные,
+// и своя квота запросов в секунду, общая для всех воркеров.
+type Client struct {
+	http      *http.Client
+	baseURL   string
+	threshold int
+	cooldown  time.Duration
+	rate      float64
+	burst     int
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	limits   map[string]*ratelimit.Bucket
+}
+
+func NewClient(cfg config.ExternalAPI) *Client {
+	return &Client{
+		http:      &http.Client{Timeout: cfg.Timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		baseURL:   cfg.URL,
+		threshold: cfg.BreakerThreshold,
+		cooldown:  cfg.BreakerCooldown,
+		rate:      cfg.RateLimit,
+		burst:     cfg.RateBurst,
+		breakers:  map[string]*breaker{},
+		limits:    map[string]*ratelimit.Bucket{},
+	}
+}
+
+// Configured сообщает, задан ли общий адрес API.
+func (cl *Client) Configured() bool {
+	return cl.baseURL != ""
+}
+
+// CircuitState - состояние предохранителя общего API: closed, open или half-open.
+func (cl *Client) CircuitState() string {
+	return cl.breaker(cl.baseURL).State()
+}
+
+func (cl *Client) breaker(apiUrl string) *breaker {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	b, ok := cl.breakers[apiUrl]
+	if !ok {
+		b = newBreaker(cl.threshold, cl.cooldown)
+		cl.breakers[apiUrl] = b
+	}
+	return b
+}
+
+// limit - квота провайдера apiUrl; nil, если квота не задана.
+func (cl *Client) limit(apiUrl string) *ratelimit.Bucket {
+	if cl.rate <= 0 {
+		return nil
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	l, ok := cl.limits[apiUrl]
+	if !ok {
+		l = ratelimit.NewBucket(cl.rate, cl.burst)
+		cl.limits[apiUrl] = l
+	}
+	return l
+}
+
+// FetchSongInfo запрашивает песню у провайдера apiUrl или, если он
+// пустой, у общего API.
+func (cl *Client) FetchSongInfo(ctx context.Context, apiUrl, group, song string) (*domain.SongInfo, error) {
+	if apiUrl == "" {
+		apiUrl = cl.baseURL
+	}
+	if apiUrl == "" {
+		return nil, fmt.Errorf("external API URL is not configured")
+	}
+
+	// Ждём квоту до предохранителя: пробный запрос, разрешённый им,
+	// не должен зависнуть в очереди за квотой.
+	if limit := cl.limit(apiUrl); limit != nil {
+		if err := limit.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for the external API rate limit: %w", err)
+		}
+	}
+
+	breaker := cl.breaker(apiUrl)
+	if !breaker.allow() {
+		metrics.ExternalAPIRequests.WithLabelValues("circuit_open").Inc()
+		return nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	data, err := cl.fetch(ctx, apiUrl, group, song)
+	metrics.ExternalAPIDuration.Observe(time.Since(start).Seconds())
+
+	result := requestResult(err)
+	metrics.ExternalAPIRequests.WithLabelValues(result).Inc()
+	switch result {
+	case "canceled":
+		// Запрос отменили мы сами, API тут ни при чём.
+		breaker.release()
+	default:
+		// 4xx - вопрос к конкретной песне, а не к доступности API.
+		breaker.record(result != "ok" && result != "client_error")
+	}
+	return data, err
+}
+
+func requestResult(err error) string {
+	var se *statusError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &se) && se.code < http.StatusInternalServerError:
+		return "client_error"
+	case errors.As(err, &se):
+		return "server_error"
+	default:
+		return "error"
+	}
+}
+
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
+func (cl *Client) fetch(ctx context.Context, apiUrl, group, song string) (*domain.SongInfo, error) {
+	url := fmt.Sprintf("%s/info?group=%s&song=%s", apiUrl, url.QueryEscape(group), url.QueryEscape(song))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := cl.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making GET request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	var apiData dto.ExternalAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiData); err != nil {
+		return nil, fmt.Errorf("error decoding: %v", err)
+	}
+
+	info := apiData.ToDomain()
+	return &info, nil
+}
@@ -0,0 +1,304 @@
+package enrichment
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"test-task/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("test-task/internal/enrichment")
+
+// Enricher - очередь фоновых задач, которые дополняют сохранённые песни
+// данными из внешнего API. Задачи обрабатывает фиксированный пул воркеров.
+type Enricher struct {
+	songService domain.SongService
+	tenants     domain.TenantService
+	client      *Client
+	jobs        chan job
+	wg          sync.WaitGroup
+
+	// mu защищает closed: после Shutdown в закрытый канал jobs писать нельзя.
+	mu     sync.RWMutex
+	closed bool
+	// stop закрывается, когда ждать очередь больше некогда: воркеры
+	// выходят, а cancel прерывает их текущие запросы к API и БД.
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// recvMu делает атомарными получение задачи воркером и пометку
+	// её как выполняемой, иначе Shutdown мог бы не увидеть задачу ни
+	// в очереди, ни среди выполняемых.
+	recvMu    sync.Mutex
+	runningMu sync.Mutex
+	running   map[int]struct{}
+
+	succeeded atomic.Int64
+	failed    atomic.Int64
+}
+
+// job - песня, контекст трассировки и логгер запроса, который её поставил.
+// Контекст переносится как W3C traceparent, а не как context.Context:
+// контекст запроса отменяется, как только клиенту ушёл ответ. Логгер
+// несёт request_id, чтобы записи задачи находились по ID запроса.
+type job struct {
+	song  domain.Song
+	trace propagation.MapCarrier
+	log   logging.Logger
+}
+
+func newJob(ctx context.Context, song domain.Song) job {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	log := logging.FromContext(ctx)
+	return job{song: song, trace: carrier, log: log.GetLoggerWithField("song_id", song.ID)}
+}
+
+// Stats - сколько задач обработано с момента запуска.
+type Stats struct {
+	Succeeded int64
+	Failed    int64
+}
+
+func NewEnricher(songService domain.SongService, tenants domain.TenantService, client *Client, workers, queueSize int) *Enricher {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Enricher{
+		songService: songService,
+		tenants:     tenants,
+		client:      client,
+		jobs:        make(chan job, queueSize),
+		stop:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+		running:     map[int]struct{}{},
+	}
+
+	for i := 0; i < workers; i++ {
+		e.wg.Add(1)
+		go e.worker()
+	}
+	return e
+}
+
+// Enqueue ставит песню в очередь, не блокируясь. Если очередь заполнена,
+// задача отбрасывается и возвращается false.
+func (e *Enricher) Enqueue(ctx context.Context, song domain.Song) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		metrics.EnrichmentJobs.WithLabelValues("rejected").Inc()
+		logging.FromContext(ctx).Warnf("enrichment is shutting down, song %d skipped", song.ID)
+		return false
+	}
+
+	select {
+	case e.jobs <- newJob(ctx, song):
+		return true
+	default:
+		metrics.EnrichmentJobs.WithLabelValues("dropped").Inc()
+		logging.FromContext(ctx).Warnf("enrichment queue is full, song %d skipped", song.ID)
+		return false
+	}
+}
+
+// EnqueueWait ставит песню в очередь, дожидаясь свободного места, но не
+// дольше, чем живёт ctx. Нужен для пакетной обработки, где терять задачи
+// нельзя.
+func (e *Enricher) EnqueueWait(ctx context.Context, song domain.Song) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		metrics.EnrichmentJobs.WithLabelValues("rejected").Inc()
+		logging.FromContext(ctx).Warnf("enrichment is shutting down, song %d skipped", song.ID)
+		return false
+	}
+
+	select {
+	case e.jobs <- newJob(ctx, song):
+		return true
+	case <-ctx.Done():
+		metrics.EnrichmentJobs.WithLabelValues("dropped").Inc()
+		logging.FromContext(ctx).Warnf("no room in the enrichment queue, song %d skipped: %v", song.ID, ctx.Err())
+		return false
+	}
+}
+
+func (e *Enricher) Stats() Stats {
+	return Stats{
+		Succeeded: e.succeeded.Load(),
+		Failed:    e.failed.Load(),
+	}
+}
+
+// Close перестаёт принимать задачи и ждёт, пока воркеры разберут очередь.
+func (e *Enricher) Close() {
+	e.Shutdown(context.Background())
+}
+
+// Shutdown перестаёт принимать задачи и ждёт, пока воркеры разберут
+// очередь, но не дольше, чем живёт ctx. Если время вышло, воркеры
+// бросают очередь и прерывают текущие задачи, а Shutdown возвращает ID песен, которые остались
+// в очереди или ещё обрабатываются, чтобы их можно было дообогатить позже.
+func (e *Enricher) Shutdown(ctx context.Context) []int {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.jobs)
+	}
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		e.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	close(e.stop)
+	e.cancel()
+
+	// Задачу из очереди забирает либо воркер, либо этот цикл,
+	// так что ни одна песня не потеряется.
+	e.recvMu.Lock()
+	pending := map[int]struct{}{}
+	for j := range e.jobs {
+		pending[j.song.ID] = struct{}{}
+	}
+	e.recvMu.Unlock()
+
+	e.runningMu.Lock()
+	for id := range e.running {
+		pending[id] = struct{}{}
+	}
+	e.runningMu.Unlock()
+
+	ids := make([]int, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (e *Enricher) worker() {
+	defer e.wg.Done()
+	for {
+		j, ok := e.next()
+		if !ok {
+			return
+		}
+
+		e.process(j)
+		e.setRunning(j.song.ID, false)
+	}
+}
+
+// next забирает задачу из очереди и помечает её выполняемой.
+// false - очередь закрыта или пора останавливаться.
+func (e *Enricher) next() (job, bool) {
+	e.recvMu.Lock()
+	defer e.recvMu.Unlock()
+
+	select {
+	case <-e.stop:
+		return job{}, false
+	default:
+	}
+
+	j, ok := <-e.jobs
+	if ok {
+		e.setRunning(j.song.ID, true)
+	}
+	return j, ok
+}
+
+func (e *Enricher) setRunning(id int, running bool) {
+	e.runningMu.Lock()
+	defer e.runningMu.Unlock()
+	if running {
+		e.running[id] = struct{}{}
+	} else {
+		delete(e.running, id)
+	}
+}
+
+func (e *Enricher) process(j job) {
+	song := j.song
+	ctx := otel.GetTextMapPropagator().Extract(e.ctx, j.trace)
+	ctx = logging.WithContext(ctx, j.log)
+	// Задачу мог поставить системный контекст всех арендаторов,
+	// а сохранять песню можно только от имени её арендатора.
+	ctx = domain.WithTenant(ctx, song.TenantID)
+	ctx, span := tracer.Start(ctx, "enrichment.process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int("song.id", song.ID), attribute.String("tenant.id", song.TenantID)),
+	)
+	defer span.End()
+
+	tenant, err := e.tenants.GetTenant(ctx, song.TenantID)
+	if err != nil && e.interrupted(j, span) {
+		return
+	}
+	if err != nil {
+		e.fail(j, span, err, "tenant lookup failed", "Error looking up tenant: ")
+		return
+	}
+
+	info, err := e.client.FetchSongInfo(ctx, tenant.LyricsURL, song.Group, song.Song)
+	if err != nil && e.interrupted(j, span) {
+		return
+	}
+	if err != nil {
+		e.fail(j, span, err, "external API request failed", "Error request to API: ")
+		return
+	}
+
+	err = e.songService.UpdateSongInfo(ctx, &song, *info)
+	if err != nil && e.interrupted(j, span) {
+		return
+	}
+	if err != nil {
+		e.fail(j, span, err, "saving song failed", "Error updating song in DB: ")
+		return
+	}
+	e.succeeded.Add(1)
+	metrics.EnrichmentJobs.WithLabelValues("succeeded").Inc()
+	j.log.Infof("Update song %d info succesfull", song.ID)
+}
+
+// fail учитывает проваленную задачу в метриках, трассе и логе.
+func (e *Enricher) fail(j job, span trace.Span, err error, status, message string) {
+	e.failed.Add(1)
+	metrics.EnrichmentJobs.WithLabelValues("failed").Inc()
+	span.RecordError(err)
+	span.SetStatus(codes.Error, status)
+	j.log.Error(message, err)
+}
+
+// interrupted сообщает, что задачу прервал Shutdown. Такая задача не
+// считается проваленной: песня попадёт в список недообогащённых.
+func (e *Enricher) interrupted(j job, span trace.Span) bool {
+	if e.ctx.Err() == nil {
+		return false
+	}
+	metrics.EnrichmentJobs.WithLabelValues("interrupted").Inc()
+	span.SetStatus(codes.Error, "interrupted by shutdown")
+	j.log.Warnf("enrichment of song %d interrupted by shutdown", j.song.ID)
+	return true
+}
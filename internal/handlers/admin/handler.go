@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/handlers"
+	"test-task/internal/middleware"
+	"test-task/internal/validation"
+	"test-task/pkg/config"
+	"test-task/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type handler struct {
+	cfg config.API
+}
+
+func NewHandler(cfg config.API) handlers.Handler {
+	return &handler{cfg: cfg}
+}
+
+func (h *handler) Register(router *gin.Engine) {
+	admin := middleware.RequireScope(domain.ScopeAdmin)
+	global := middleware.RequireGlobal()
+
+	getLevel := gin.HandlersChain{admin, global, h.GetLogLevel}
+	setLevel := gin.HandlersChain{admin, global, middleware.BodyLimit(int64(h.cfg.MaxBodyKB) << 10), h.SetLogLevel}
+
+	v1 := router.Group(handlers.APIv1)
+	v1.GET("/admin/log-level", getLevel...)
+	v1.PUT("/admin/log-level", setLevel...)
+
+	if h.cfg.LegacyRoutes {
+		deprecated := middleware.Deprecated(handlers.APIv1+"/admin/log-level", handlers.LegacyDeprecatedAt, h.cfg.Sunset())
+		router.GET("/admin/log-level", append(gin.HandlersChain{deprecated}, getLevel...)...)
+		router.PUT("/admin/log-level", append(gin.HandlersChain{deprecated}, setLevel...)...)
+	}
+}
+
+// @Summary Текущий уровень журнала
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} dto.LogLevel
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/log-level [get]
+// @DeprecatedRouter /admin/log-level [get]
+func (h *handler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevel{Level: logging.Level()})
+}
+
+// @Summary Изменить уровень журнала
+// @Description Меняет уровень журнала до перезапуска, без перечитывания настроек
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body dto.LogLevel true "Новый уровень"
+// @Success 200 {object} dto.LogLevel
+// @Failure 400 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/log-level [put]
+// @DeprecatedRouter /admin/log-level [put]
+func (h *handler) SetLogLevel(c *gin.Context) {
+	var req dto.LogLevel
+	if err := validation.BindJSON(c, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := logging.SetLevel(req.Level); err != nil {
+		c.Error(fmt.Errorf("%w: %v", domain.ErrInvalidInput, err))
+		return
+	}
+	logging.FromContext(c.Request.Context()).Warnf("log level set to %s", req.Level)
+	c.JSON(http.StatusOK, dto.LogLevel{Level: logging.Level()})
+}
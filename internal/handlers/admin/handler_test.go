@@ -0,0 +1,63 @@
+package admin_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/internal/handlers/admin"
+	"test-task/internal/middleware"
+	"test-task/pkg/config"
+	"test-task/pkg/logging"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubVerifier выдаёт вызывающих по токену вместо проверки JWT.
+type stubVerifier map[string]*domain.Principal
+
+func (v stubVerifier) Verify(ctx context.Context, token string) (*domain.Principal, error) {
+	if p, ok := v[token]; ok {
+		return p, nil
+	}
+	return nil, domain.ErrUnauthorized
+}
+
+func TestLogLevelRequiresGlobalAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := stubVerifier{
+		"global.admin.token":  {Subject: "root", Scopes: []domain.Scope{domain.ScopeAdmin}},
+		"tenant.admin.token":  {Subject: "label-admin", Tenant: "label-a", Scopes: []domain.Scope{domain.ScopeAdmin}},
+		"global.editor.token": {Subject: "editor", Scopes: []domain.Scope{domain.ScopeSongsWrite}},
+	}
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.Authenticate(nil, users))
+	admin.NewHandler(config.Default().API).Register(r)
+
+	level := logging.Level()
+	t.Cleanup(func() { logging.SetLevel(level) })
+
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"global.editor.token", http.StatusForbidden},
+		{"tenant.admin.token", http.StatusForbidden},
+		{"global.admin.token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(`{"level":"`+level+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("token %q: status = %d, want %d, body %s", tt.token, w.Code, tt.want, w.Body)
+		}
+	}
+}
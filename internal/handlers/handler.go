@@ -1,6 +1,16 @@
 package handlers
 
-import "github.com/gin-gonic/gin"
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIv1 - префикс текущей версии API.
+const APIv1 = "/api/v1"
+
+// LegacyDeprecatedAt - с этой даты маршруты без версии устарели.
+var LegacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
 
 type Handler interface {
 	Register(router *gin.Engine)
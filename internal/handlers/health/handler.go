@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"test-task/internal/dto"
+	"test-task/internal/handlers"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check - проверка одной зависимости. Если критичная проверка не прошла,
+// сервис не готов (503); некритичная только понижает статус до degraded.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+type handler struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewHandler(checks []Check, timeout time.Duration) handlers.Handler {
+	return &handler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+func (h *handler) Register(router *gin.Engine) {
+	router.GET("/healthz", h.Live)
+	router.GET("/readyz", h.Ready)
+}
+
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс жив
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dto.HealthResponse
+// @Router /healthz [get]
+func (h *handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.HealthResponse{Status: dto.HealthOK})
+}
+
+// @Summary Проверка готовности
+// @Description Проверяет БД, состояние миграций и внешний API, возвращает результат по каждой зависимости
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dto.HealthResponse
+// @Failure 503 {object} dto.HealthResponse
+// @Router /readyz [get]
+func (h *handler) Ready(c *gin.Context) {
+	resp := dto.HealthResponse{
+		Status: dto.HealthOK,
+		Checks: make(map[string]dto.HealthCheck, len(h.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(c.Request.Context(), check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[check.Name] = result
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Checks {
+		switch {
+		case result.Status == dto.HealthOK:
+		case result.Critical:
+			resp.Status = dto.HealthFail
+		case resp.Status == dto.HealthOK:
+			resp.Status = dto.HealthDegraded
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status == dto.HealthFail {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, resp)
+}
+
+func (h *handler) run(ctx context.Context, check Check) dto.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+
+	result := dto.HealthCheck{
+		Status:   dto.HealthOK,
+		Critical: check.Critical,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+	if err != nil {
+		result.Status = dto.HealthFail
+		result.Error = err.Error()
+	}
+	return result
+}
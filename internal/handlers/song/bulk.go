@@ -0,0 +1,278 @@
+package song
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/validation"
+	"test-task/pkg/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxBulkItems   = 10000
+	maxNDJSONLine  = 1 << 20
+	ndjsonMimeType = "application/x-ndjson"
+	// enqueueWait - сколько одна песня ждёт места в очереди обогащения.
+	enqueueWait = 100 * time.Millisecond
+)
+
+// bulkItem - одна запись из тела запроса. err заполнен, если запись
+// не удалось разобрать, но остальные записи обработать можно.
+type bulkItem struct {
+	req dto.CreateSongRequest
+	err error
+}
+
+// @Summary Массовое добавление песен
+// @Description Принимает JSON-массив или NDJSON (Content-Type: application/x-ndjson) и возвращает статус по каждой песне.
+// @Description С atomic=true песни сохраняются только если все они валидны и не являются дубликатами.
+// @Tags Songs
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param songs body []dto.CreateSongRequest true "Песни"
+// @Param atomic query bool false "Сохранить всё или ничего"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
+// @Success 200 {object} dto.BulkResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 422 {object} dto.BulkResponse
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/bulk [post]
+// @DeprecatedRouter /songs/bulk [post]
+func (h *handler) BulkAddSongs(c *gin.Context) {
+	// Импорт может идти дольше ReadTimeout и WriteTimeout сервера.
+	extendDeadlines(c, h.cfg.BulkTimeout)
+
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "false"))
+	if err != nil {
+		c.Error(fmt.Errorf("%w: invalid atomic flag: %v", domain.ErrInvalidInput, err))
+		return
+	}
+
+	items, err := decodeBulk(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resp := dto.BulkResponse{
+		Atomic:  atomic,
+		Results: make([]dto.BulkItemResult, len(items)),
+	}
+
+	songs := make([]*domain.Song, 0, len(items))
+	idx := make([]int, 0, len(items))
+	for i := range items {
+		result := &resp.Results[i]
+		result.Index = i
+
+		if err := items[i].err; err != nil {
+			result.Status = string(domain.ImportInvalid)
+			result.Reason = err.Error()
+			continue
+		}
+
+		req := &items[i].req
+		if err := validation.Validate(req); err != nil {
+			result.Status = string(domain.ImportInvalid)
+			result.Reason = "validation failed"
+			var verrs validation.Errors
+			if errors.As(err, &verrs) {
+				result.Errors = verrs
+			}
+			continue
+		}
+
+		songs = append(songs, req.ToDomain())
+		idx = append(idx, i)
+	}
+
+	if atomic && len(songs) != len(items) {
+		for _, i := range idx {
+			resp.Results[i].Status = string(domain.ImportSkipped)
+			resp.Results[i].Reason = "import aborted: batch contains invalid items"
+		}
+		h.writeBulkResponse(c, resp)
+		return
+	}
+
+	imported, err := h.songService.ImportSongs(c.Request.Context(), songs, domain.ImportOptions{Atomic: atomic})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	created := 0
+	enqueue := h.bulkEnqueuer(c.Request.Context())
+	for j, res := range imported {
+		result := &resp.Results[idx[j]]
+		result.Status = string(res.Status)
+		result.Reason = res.Reason
+		if res.Status == domain.ImportCreated {
+			result.ID = res.Song.ID
+			created++
+			if enqueue(*res.Song) {
+				resp.Enqueued++
+			}
+		}
+	}
+	if resp.Enqueued < created {
+		logging.FromContext(c.Request.Context()).Warnf("%d of %d imported songs not queued for enrichment", created-resp.Enqueued, created)
+	}
+
+	h.writeBulkResponse(c, resp)
+}
+
+// bulkEnqueuer ставит песни в очередь обогащения. Каждая ждёт места не
+// дольше enqueueWait, а после первой неудачи остальные ставятся без
+// ожидания: полная очередь не должна держать запрос. Песни, не попавшие
+// в очередь, останутся без текста до команды enrich.
+func (h *handler) bulkEnqueuer(ctx context.Context) func(domain.Song) bool {
+	wait := true
+	return func(s domain.Song) bool {
+		if !wait {
+			return h.enricher.Enqueue(ctx, s)
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, enqueueWait)
+		defer cancel()
+		if h.enricher.EnqueueWait(waitCtx, s) {
+			return true
+		}
+		wait = false
+		return false
+	}
+}
+
+func (h *handler) writeBulkResponse(c *gin.Context, resp dto.BulkResponse) {
+	for _, r := range resp.Results {
+		switch domain.ImportStatus(r.Status) {
+		case domain.ImportCreated:
+			resp.Created++
+		case domain.ImportDuplicate:
+			resp.Duplicates++
+		case domain.ImportInvalid:
+			resp.Invalid++
+		case domain.ImportSkipped:
+			resp.Skipped++
+		}
+	}
+
+	resp.Committed = !resp.Atomic || resp.Created == len(resp.Results)
+	logging.FromContext(c.Request.Context()).Infof("bulk import: %d created, %d duplicates, %d invalid, %d skipped",
+		resp.Created, resp.Duplicates, resp.Invalid, resp.Skipped)
+
+	status := http.StatusOK
+	if !resp.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, resp)
+}
+
+func decodeBulk(c *gin.Context) ([]bulkItem, error) {
+	var (
+		items []bulkItem
+		err   error
+	)
+
+	switch c.ContentType() {
+	case ndjsonMimeType, "application/ndjson", "application/jsonl":
+		items, err = decodeNDJSON(c.Request.Body)
+	default:
+		items, err = decodeJSONArray(c.Request.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no songs in request", domain.ErrInvalidInput)
+	}
+	return items, nil
+}
+
+func decodeJSONArray(body io.Reader) ([]bulkItem, error) {
+	dec := validation.NewDecoder(body)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON: %w", domain.ErrInvalidInput, err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: expected a JSON array of songs", domain.ErrInvalidInput)
+	}
+
+	var items []bulkItem
+	for dec.More() {
+		if len(items) == maxBulkItems {
+			return nil, tooManyItems()
+		}
+
+		var item bulkItem
+		if err := dec.Decode(&item.req); err != nil {
+			// Ошибка типа или лишнее поле не ломают поток: запись
+			// помечается невалидной, а синтаксическая ошибка делает
+			// невалидным весь запрос.
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) && !validation.UnknownField(err) {
+				return nil, fmt.Errorf("%w: invalid JSON in item %d: %w", domain.ErrInvalidInput, len(items), err)
+			}
+			item.err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON: %w", domain.ErrInvalidInput, err)
+	}
+	return items, nil
+}
+
+func decodeNDJSON(body io.Reader) ([]bulkItem, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	var items []bulkItem
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == maxBulkItems {
+			return nil, tooManyItems()
+		}
+
+		var item bulkItem
+		dec := validation.NewDecoder(bytes.NewReader(line))
+		if err := dec.Decode(&item.req); err != nil {
+			item.err = fmt.Errorf("invalid JSON: %v", err)
+		} else if dec.More() {
+			item.err = fmt.Errorf("invalid JSON: unexpected data after the object")
+		}
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: reading NDJSON: %w", domain.ErrInvalidInput, err)
+	}
+	return items, nil
+}
+
+func tooManyItems() error {
+	return fmt.Errorf("%w: too many songs in one request (max %d)", domain.ErrInvalidInput, maxBulkItems)
+}
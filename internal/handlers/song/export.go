@@ -0,0 +1,316 @@
+package song
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Через сколько строк выгрузки сбрасывать буфер клиенту.
+const exportFlushEvery = 500
+
+type exportField struct {
+	name  string
+	value func(s *domain.Song) any
+}
+
+// Поля выгрузки, названия совпадают с JSON-полями dto.SongResponse.
+var exportFields = []exportField{
+	{"id", func(s *domain.Song) any { return s.ID }},
+	{"group", func(s *domain.Song) any { return s.Group }},
+	{"song", func(s *domain.Song) any { return s.Song }},
+	{"text", func(s *domain.Song) any { return s.Text }},
+	{"releaseDate", func(s *domain.Song) any {
+		if s.ReleaseDate.IsZero() {
+			return nil
+		}
+		return s.ReleaseDate
+	}},
+	{"link", func(s *domain.Song) any { return s.Link }},
+}
+
+// rowWriter пишет выгрузку в конкретном формате.
+type rowWriter interface {
+	WriteRow(s *domain.Song) error
+	Close() error
+}
+
+var exportFormats = map[string]struct {
+	contentType string
+	newWriter   func(w io.Writer, fields []exportField) (rowWriter, error)
+}{
+	"ndjson": {"application/x-ndjson", newNDJSONWriter},
+	"json":   {"application/json", newJSONWriter},
+	"csv":    {"text/csv; charset=utf-8", newCSVWriter},
+}
+
+// @Summary Выгрузка каталога песен
+// @Description Потоково выгружает все песни, подходящие под фильтры, в формате NDJSON, JSON или CSV
+// @Tags Songs
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param group query string false "Фильтр по группе"
+// @Param song query string false "Фильтр по названию песни"
+// @Param format query string false "Формат выгрузки" Enums(ndjson, json, csv) default(ndjson)
+// @Param fields query string false "Поля через запятую: id,group,song,text,releaseDate,link"
+// @Param gzip query bool false "Сжать ответ gzip"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
+// @Success 200 {array} dto.SongResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/export [get]
+// @DeprecatedRouter /songs/export [get]
+func (h *handler) ExportSongs(c *gin.Context) {
+	format := c.DefaultQuery("format", "ndjson")
+	spec, ok := exportFormats[format]
+	if !ok {
+		c.Error(fmt.Errorf("%w: unknown export format %q", domain.ErrInvalidInput, format))
+		return
+	}
+
+	fields, err := parseExportFields(c.Query("fields"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	compress, err := strconv.ParseBool(c.DefaultQuery("gzip", "false"))
+	if err != nil {
+		c.Error(fmt.Errorf("%w: invalid gzip flag: %v", domain.ErrInvalidInput, err))
+		return
+	}
+
+	// Выгрузка может идти дольше WriteTimeout сервера.
+	extendDeadlines(c, h.cfg.ExportTimeout)
+
+	var (
+		gz  *gzip.Writer
+		buf *bufio.Writer
+		rw  rowWriter
+	)
+	// begin отправляет заголовки. Вызывается, когда запрос к БД уже
+	// вернул первую строку или закончился без строк: ошибку до этого
+	// момента клиент ещё получит обычным ответом.
+	begin := func() error {
+		var out io.Writer = c.Writer
+		if compress {
+			gz = gzip.NewWriter(c.Writer)
+			out = gz
+		}
+		buf = bufio.NewWriter(out)
+		w, err := spec.newWriter(buf, fields)
+		if err != nil {
+			return err
+		}
+		rw = w
+
+		c.Header("Content-Type", spec.contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="songs.%s"`, format))
+		if compress {
+			c.Header("Content-Encoding", "gzip")
+		}
+		c.Status(http.StatusOK)
+		return nil
+	}
+
+	rows := 0
+	filter := domain.SongFilter{Group: c.Query("group"), Song: c.Query("song")}
+	err = h.songService.ExportSongs(c.Request.Context(), filter, func(s *domain.Song) error {
+		if rw == nil {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := rw.WriteRow(s); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := buf.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && rw == nil {
+		c.Error(err)
+		return
+	}
+	if err == nil && rw == nil {
+		err = begin()
+	}
+	if err == nil {
+		err = rw.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+
+	log := logging.FromContext(c.Request.Context())
+	if err != nil {
+		// Заголовки уже отправлены, и 200 с обрезанной выгрузкой выглядел бы
+		// как полная. Обрываем соединение без завершающего блока chunked
+		// и без трейлера gzip, чтобы клиент увидел ошибку.
+		log.Error("export aborted: ", err)
+		panic(http.ErrAbortHandler)
+	}
+	log.Infof("exported %d songs as %s", rows, format)
+}
+
+func parseExportFields(raw string) ([]exportField, error) {
+	if raw == "" {
+		return exportFields, nil
+	}
+
+	var fields []exportField
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range exportFields {
+			if f.name == name {
+				fields = append(fields, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown export field %q", domain.ErrInvalidInput, name)
+		}
+	}
+	return fields, nil
+}
+
+type ndjsonWriter struct {
+	w      io.Writer
+	fields []exportField
+}
+
+func newNDJSONWriter(w io.Writer, fields []exportField) (rowWriter, error) {
+	return &ndjsonWriter{w: w, fields: fields}, nil
+}
+
+func (n *ndjsonWriter) WriteRow(s *domain.Song) error {
+	if err := writeJSONObject(n.w, n.fields, s); err != nil {
+		return err
+	}
+	_, err := io.WriteString(n.w, "\n")
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+type jsonWriter struct {
+	w      io.Writer
+	fields []exportField
+	rows   int
+}
+
+func newJSONWriter(w io.Writer, fields []exportField) (rowWriter, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+	return &jsonWriter{w: w, fields: fields}, nil
+}
+
+func (j *jsonWriter) WriteRow(s *domain.Song) error {
+	if j.rows > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.rows++
+	return writeJSONObject(j.w, j.fields, s)
+}
+
+func (j *jsonWriter) Close() error {
+	_, err := io.WriteString(j.w, "]")
+	return err
+}
+
+// writeJSONObject пишет объект с полями в заданном порядке,
+// чего не даёт json.Marshal для map.
+func writeJSONObject(w io.Writer, fields []exportField, s *domain.Song) error {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		val, err := json.Marshal(f.value(s))
+		if err != nil {
+			return err
+		}
+		sb.WriteString(strconv.Quote(f.name))
+		sb.WriteByte(':')
+		sb.Write(val)
+	}
+	sb.WriteByte('}')
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	fields []exportField
+	record []string
+}
+
+func newCSVWriter(w io.Writer, fields []exportField) (rowWriter, error) {
+	cw := &csvWriter{
+		w:      csv.NewWriter(w),
+		fields: fields,
+		record: make([]string, len(fields)),
+	}
+
+	for i, f := range fields {
+		cw.record[i] = f.name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) WriteRow(s *domain.Song) error {
+	for i, f := range cw.fields {
+		switch v := f.value(s).(type) {
+		case nil:
+			cw.record[i] = ""
+		case int:
+			cw.record[i] = strconv.Itoa(v)
+		case time.Time:
+			cw.record[i] = v.Format(time.RFC3339)
+		default:
+			cw.record[i] = fmt.Sprint(v)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
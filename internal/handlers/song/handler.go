@@ -1,17 +1,17 @@
 package song
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
-	"os"
 	"strconv"
-	"strings"
 	"test-task/internal/domain"
 	"test-task/internal/dto"
 	"test-task/internal/handlers"
-	"test-task/pkg/logging"
+	"test-task/internal/middleware"
+	"test-task/internal/validation"
+	"test-task/pkg/config"
+	"test-task/pkg/ratelimit"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -21,26 +21,81 @@ import (
 
 type handler struct {
 	songService domain.SongService
-	log         logging.Logger
+	enricher    domain.SongEnricher
+	cfg         config.API
+	limits      config.RateLimit
 }
 
-func NewHandler(songService domain.SongService) handlers.Handler {
+func NewHandler(songService domain.SongService, enricher domain.SongEnricher, cfg config.API, limits config.RateLimit) handlers.Handler {
 	return &handler{
 		songService: songService,
-		log:         logging.GetLogger(),
+		enricher:    enricher,
+		cfg:         cfg,
+		limits:      limits,
 	}
 }
 
 func (h *handler) Register(router *gin.Engine) {
-	router.GET("/songs", h.GetSongs)
-	router.GET("/verse/:song_id", h.GetText)
-	router.DELETE("/song/:song_id", h.DeleteSong)
-	router.PATCH("/song/:song_id", h.UpdateSong)
-	router.POST("/song", h.FetchAndUpdateSongInfo(), h.AddSong)
+	timeout := middleware.Timeout(h.cfg.RequestTimeout)
+	read := middleware.RequireScope(domain.ScopeSongsRead)
+	write := middleware.RequireScope(domain.ScopeSongsWrite)
+
+	// Лимит проверяется до прав: запросы без ключа тоже надо ограничивать.
+	readLimit := h.limit("read", h.limits.Read, h.limits.ReadBurst)
+	writeLimit := h.limit("write", h.limits.Write, h.limits.WriteBurst)
+	createLimit := h.limit("create", h.limits.Create, h.limits.CreateBurst)
+	bulkLimit := h.limit("bulk", h.limits.Bulk, h.limits.BulkBurst)
+
+	body := middleware.BodyLimit(int64(h.cfg.MaxBodyKB) << 10)
+	bulkBody := middleware.BodyLimit(int64(h.cfg.BulkMaxBodyMB) << 20)
+
+	list := gin.HandlersChain{readLimit, read, timeout, h.GetSongs}
+	export := gin.HandlersChain{bulkLimit, read, middleware.Timeout(h.cfg.ExportTimeout), h.ExportSongs}
+	bulk := gin.HandlersChain{bulkLimit, write, bulkBody, middleware.Timeout(h.cfg.BulkTimeout), h.BulkAddSongs}
+	get := gin.HandlersChain{readLimit, read, timeout, h.GetSong}
+	verses := gin.HandlersChain{readLimit, read, timeout, h.GetText}
+	remove := gin.HandlersChain{writeLimit, write, timeout, h.DeleteSong}
+	update := gin.HandlersChain{writeLimit, write, body, timeout, h.UpdateSong}
+	create := gin.HandlersChain{createLimit, write, body, timeout, h.FetchAndUpdateSongInfo(), h.AddSong}
+
+	v1 := router.Group(handlers.APIv1)
+	v1.GET("/songs", list...)
+	v1.POST("/songs", create...)
+	v1.GET("/songs/export", export...)
+	v1.POST("/songs/bulk", bulk...)
+	v1.GET("/songs/:id", get...)
+	v1.PATCH("/songs/:id", update...)
+	v1.DELETE("/songs/:id", remove...)
+	v1.GET("/songs/:id/verses", verses...)
+
+	// Старые маршруты - те же цепочки, поэтому и лимиты у них общие с /api/v1.
+	if h.cfg.LegacyRoutes {
+		legacy := func(successor string, chain gin.HandlersChain) gin.HandlersChain {
+			deprecated := middleware.Deprecated(handlers.APIv1+successor, handlers.LegacyDeprecatedAt, h.cfg.Sunset())
+			return append(gin.HandlersChain{deprecated}, chain...)
+		}
+		router.GET("/songs", legacy("/songs", list)...)
+		router.GET("/songs/export", legacy("/songs/export", export)...)
+		router.POST("/songs/bulk", legacy("/songs/bulk", bulk)...)
+		router.GET("/verse/:id", legacy("/songs/:id/verses", verses)...)
+		router.DELETE("/song/:id", legacy("/songs/:id", remove)...)
+		router.PATCH("/song/:id", legacy("/songs/:id", update)...)
+		router.POST("/song", legacy("/songs", create)...)
+	}
+
+	// Заглушка внешнего API для локального запуска, её вызывает само приложение.
 	router.GET("/info", h.FakeExternalApi)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
 
+// limit - ограничение частоты для группы маршрутов name со своими счётчиками.
+func (h *handler) limit(name string, rate float64, burst int) gin.HandlerFunc {
+	if !h.limits.Enabled {
+		return middleware.RateLimit(name, nil)
+	}
+	return middleware.RateLimit(name, ratelimit.NewLimiter(rate, burst))
+}
+
 // @Summary Получение списка песен
 // @Description Возвращает список песен с пагинацией и фильтрацией
 // @Tags Songs
@@ -50,35 +105,59 @@ func (h *handler) Register(router *gin.Engine) {
 // @Param song query string false "Фильтр по названию песни"
 // @Param page query int false "Номер страницы"
 // @Param limit query int false "Лимит на страницу"
-// @Success 200 {array} []dto.Song
-// @Failure 500 {object} dto.ResponseError
-// @Router /songs [get]
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
+// @Success 200 {array} dto.SongResponse
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs [get]
+// @DeprecatedRouter /songs [get]
 func (h *handler) GetSongs(c *gin.Context) {
 	groupName := c.Query("group")
 	songName := c.Query("song")
 
-	page, limit := parsePagination(c)
+	page, limit := h.parsePagination(c)
 
-	songs, err := h.songService.GetSongs(groupName, songName, page, limit)
+	songs, err := h.songService.GetSongs(c.Request.Context(), groupName, songName, page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
-	var song_responces []dto.Song
-	for _, song := range songs {
-		song_responce := dto.Song{
-			ID:          song.ID,
-			Group:       song.Group,
-			Song:        song.Song,
-			ReleaseDate: song.ReleaseDate,
-			Text:        song.Text,
-			Link:        song.Link,
-		}
-		song_responces = append(song_responces, song_responce)
+	c.JSON(http.StatusOK, dto.NewSongsResponse(songs))
+}
+
+// @Summary Получение песни
+// @Description Возвращает песню по ID
+// @Tags Songs
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
+// @Success 200 {object} dto.SongResponse
+// @Failure 400 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/{id} [get]
+func (h *handler) GetSong(c *gin.Context) {
+	id, err := parseSongID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	song, err := h.songService.GetSong(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, song_responces)
+	c.JSON(http.StatusOK, dto.NewSongResponse(song))
 }
 
 // @Summary Получение текста песен
@@ -86,31 +165,32 @@ func (h *handler) GetSongs(c *gin.Context) {
 // @Tags Songs
 // @Accept json
 // @Produce json
-// @Param song_id path int true "ID песни"
+// @Param id path int true "ID песни"
 // @Param page query int false "Номер страницы"
 // @Param limit query int false "Лимит на страницу"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
 // @Success 200 {array} []string
-// @Failure 400 {object} dto.ResponseError
-// @Failure 404 {object} dto.ResponseError
-// @Failure 500 {object} dto.ResponseError
-// @Router /songs/{song_id} [get]
+// @Failure 400 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/{id}/verses [get]
+// @DeprecatedRouter /verse/{id} [get]
 func (h *handler) GetText(c *gin.Context) {
 	id, err := parseSongID(c)
 	if err != nil {
-		h.log.Error(err.Error())
-		c.JSON(http.StatusBadRequest, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
-	page, limit := parsePagination(c)
+	page, limit := h.parsePagination(c)
 
-	text, err := h.songService.GetTextBySongID(id, page, limit)
+	text, err := h.songService.GetTextBySongID(c.Request.Context(), id, page, limit)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, dto.ResponseError{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -122,21 +202,27 @@ func (h *handler) GetText(c *gin.Context) {
 // @Tags Songs
 // @Accept json
 // @Produce json
-// @Param song_id path int true "ID песни"
-// @Success 200 {object} dto.ResponseMessageWithData
-// @Failure 400 {object} dto.ResponseError
-// @Failure 500 {object} dto.ResponseError
-// @Router /song/{song_id} [delete]
+// @Param id path int true "ID песни"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
+// @Success 200 {object} dto.ResponseMessage
+// @Failure 400 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/{id} [delete]
+// @DeprecatedRouter /song/{id} [delete]
 func (h *handler) DeleteSong(c *gin.Context) {
 	id, err := parseSongID(c)
 	if err != nil {
-		h.log.Error(err.Error())
-		c.JSON(http.StatusBadRequest, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
-	if err := h.songService.DeleteSong(id); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ResponseError{Error: err.Error()})
+	if err := h.songService.DeleteSong(c.Request.Context(), id); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -148,56 +234,42 @@ func (h *handler) DeleteSong(c *gin.Context) {
 // @Tags Songs
 // @Accept json
 // @Produce json
-// @Param song_id path int true "ID песни"
-// @Param song body dto.SongRequest true "Обновляемые данные"
+// @Param id path int true "ID песни"
+// @Param song body dto.UpdateSongRequest true "Обновляемые данные"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
 // @Success 200 {object} dto.ResponseMessageWithData
-// @Failure 400 {object} dto.ResponseError
-// @Failure 404 {object} dto.ResponseError
-// @Failure 500 {object} dto.ResponseError
-// @Router /song/{song_id} [patch]
+// @Failure 400 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 404 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs/{id} [patch]
+// @DeprecatedRouter /song/{id} [patch]
 func (h *handler) UpdateSong(c *gin.Context) {
 	id, err := parseSongID(c)
 	if err != nil {
-		h.log.Error(err.Error())
-		c.JSON(http.StatusBadRequest, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
-	var updateSong dto.SongRequest
-	if err := c.ShouldBindJSON(&updateSong); err != nil {
-		h.log.Error("parsing JSON: ", err)
-		c.JSON(http.StatusBadRequest, dto.ResponseError{Error: err.Error()})
+	var req dto.UpdateSongRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	song := &domain.Song{
-		Group: updateSong.Group,
-		Song:  updateSong.Song,
-	}
-
-	updatedSong, err := h.songService.UpdateSong(id, song)
+	updatedSong, err := h.songService.UpdateSong(c.Request.Context(), id, req.ToDomain())
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			c.JSON(http.StatusNotFound, dto.ResponseError{Error: err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, dto.ResponseError{Error: err.Error()})
+		c.Error(err)
 		return
 	}
 
-	song_responce := dto.Song{
-		ID:          updatedSong.ID,
-		Group:       updatedSong.Group,
-		Song:        updatedSong.Song,
-		ReleaseDate: updatedSong.ReleaseDate,
-		Text:        updatedSong.Text,
-		Link:        updatedSong.Link,
-	}
-
-	c.JSON(http.StatusOK, dto.ResponseMessageWithData{
-		Message: "Song updated",
-		Result:  song_responce,
-	})
+	c.JSON(http.StatusOK, dto.NewSongMessage("Song updated", updatedSong))
 }
 
 // @Summary Добавление новой песни
@@ -205,118 +277,102 @@ func (h *handler) UpdateSong(c *gin.Context) {
 // @Tags Songs
 // @Accept json
 // @Produce json
-// @Param song body dto.SongRequest true "Данные песни"
+// @Param song body dto.CreateSongRequest true "Данные песни"
+// @Param X-Tenant-ID header string false "Арендатор; выбрать может только ключ или пользователь без арендатора с правом admin"
 // @Success 201 {object} dto.ResponseMessageWithData
-// @Failure 400 {object} dto.ResponseError
-// @Failure 500 {object} dto.ResponseError
-// @Router /song [post]
+// @Failure 400 {object} dto.Problem
+// @Failure 413 {object} dto.Problem
+// @Failure 409 {object} dto.Problem
+// @Failure 422 {object} dto.Problem
+// @Failure 500 {object} dto.Problem
+// @Failure 401 {object} dto.Problem
+// @Failure 403 {object} dto.Problem
+// @Failure 429 {object} dto.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/songs [post]
+// @DeprecatedRouter /song [post]
 func (h *handler) AddSong(c *gin.Context) {
 	song, exists := c.Get("song")
 	if !exists {
-		h.log.Error("No data song")
-		c.JSON(http.StatusBadRequest, dto.ResponseError{Error: "No data song"})
+		c.Error(fmt.Errorf("%w: no data song", domain.ErrInvalidInput))
 		return
 	}
 
 	newSong := song.(*domain.Song)
 
-	if err := h.songService.CreateSong(newSong); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ResponseError{Error: err.Error()})
+	if err := h.songService.CreateSong(c.Request.Context(), newSong); err != nil {
+		c.Error(err)
 		return
 	}
 
-	song_responce := dto.Song{
-		ID:          newSong.ID,
-		Group:       newSong.Group,
-		Song:        newSong.Song,
-		ReleaseDate: newSong.ReleaseDate,
-		Text:        newSong.Text,
-		Link:        newSong.Link,
-	}
-
-	c.JSON(http.StatusCreated, dto.ResponseMessageWithData{
-		Message: "Song added",
-		Result:  song_responce,
-	})
+	c.JSON(http.StatusCreated, dto.NewSongMessage("Song added", newSong))
 }
 
 func (h *handler) FakeExternalApi(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"text":        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
-		"releaseDate": "2025-03-28T21:22:19Z",
-		"link":        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	c.JSON(http.StatusOK, dto.ExternalAPIResponse{
+		Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\nYou caught me under false pretenses\nHow long before you let me go?\n\nOoh\nYou set my soul alight\nOoh\nYou set my soul alight",
+		ReleaseDate: time.Date(2025, time.March, 28, 21, 22, 19, 0, time.UTC),
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
 	})
 }
 
+// FetchAndUpdateSongInfo разбирает данные новой песни для AddSong и,
+// если песня сохранена, ставит её в очередь на обогащение из внешнего API.
 func (h *handler) FetchAndUpdateSongInfo() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var song domain.Song
-		if err := c.ShouldBindJSON(&song); err != nil {
-			h.log.Error("parsing JSON: ", err)
-			c.JSON(http.StatusBadRequest, dto.ResponseError{Error: err.Error()})
+		var req dto.CreateSongRequest
+		if err := validation.BindJSON(c, &req); err != nil {
+			c.Error(err)
+			c.Abort()
 			return
 		}
 
-		c.Set("song", &song)
-
-		go func(song domain.Song) {
-			data, err := fetchSongInfo(song.Group, song.Song)
-			if err != nil {
-				h.log.Error("Error request to API: ", err)
-				return
-			}
-
-			if err := h.songService.UpdateSongInfo(&song, *data); err != nil {
-				h.log.Error("Error updating song in DB: ", err)
-				return
-			}
-			h.log.Info("Update song info succesfull")
-		}(song)
+		song := req.ToDomain()
+		c.Set("song", song)
 
 		c.Next()
 
+		if c.Writer.Status() != http.StatusCreated || len(c.Errors) > 0 {
+			return
+		}
+		h.enricher.Enqueue(c.Request.Context(), *song)
 	}
 }
 
-func fetchSongInfo(group, song string) (*dto.ExternalAPIResponse, error) {
-	apiUrl := os.Getenv("EXTERNAL_API_URL")
-	if apiUrl == "" {
-		return nil, fmt.Errorf("EXTERNAL_API_URL is not set")
-	}
-
-	url := fmt.Sprintf("%s/info?group=%s&song=%s", apiUrl, url.QueryEscape(group), url.QueryEscape(song))
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("error making GET request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var apiData dto.ExternalAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiData); err != nil {
-		return nil, fmt.Errorf("error decoding: %v", err)
-	}
-
-	return &apiData, nil
-}
-
 func parseSongID(c *gin.Context) (int, error) {
-	id, err := strconv.Atoi(c.Param("song_id"))
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return 0, fmt.Errorf("invalid song_id format: %v", err)
+		return 0, fmt.Errorf("%w: invalid song id format: %v", domain.ErrInvalidInput, err)
 	}
 	return id, nil
 }
 
-func parsePagination(c *gin.Context) (int, int) {
+// parsePagination читает page и limit; limit больше api.max_page_size
+// урезается до него.
+func (h *handler) parsePagination(c *gin.Context) (int, int) {
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page < 1 {
 		page = 1
 	}
 
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	limit, err := strconv.Atoi(c.Query("limit"))
 	if err != nil || limit < 1 {
-		limit = 10
+		limit = h.cfg.DefaultPageSize
+	}
+	if limit > h.cfg.MaxPageSize {
+		limit = h.cfg.MaxPageSize
 	}
 
 	return page, limit
 }
+
+// extendDeadlines отодвигает ReadTimeout и WriteTimeout сервера для
+// маршрутов, которым по api.*_timeout разрешено работать дольше них.
+// Без этого сервер оборвёт соединение, когда запрос уже выполнен,
+// и клиент не узнает результат.
+func extendDeadlines(c *gin.Context, d time.Duration) {
+	rc := http.NewResponseController(c.Writer)
+	deadline := time.Now().Add(d)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+}
@@ -0,0 +1,496 @@
+package song_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/handlers/song"
+	"test-task/internal/middleware"
+	"test-task/internal/repository"
+	"test-task/internal/services"
+	"test-task/pkg/config"
+	"test-task/pkg/db"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubEnricher запоминает песни, поставленные в очередь.
+type stubEnricher struct {
+	mu    sync.Mutex
+	songs []domain.Song
+}
+
+func (e *stubEnricher) Enqueue(ctx context.Context, s domain.Song) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.songs = append(e.songs, s)
+	return true
+}
+
+func (e *stubEnricher) EnqueueWait(ctx context.Context, s domain.Song) bool {
+	return e.Enqueue(ctx, s)
+}
+
+func newRouter(t *testing.T) (*gin.Engine, *stubEnricher) {
+	t.Helper()
+	return newRouterWithRepo(t, repository.NewMemorySongRepo())
+}
+
+func newRouterWithRepo(t *testing.T, repo domain.SongRepository) (*gin.Engine, *stubEnricher) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tenantRepo := repository.NewMemoryTenantRepo()
+	svc := services.NewSongService(repo, tenantRepo)
+	enricher := &stubEnricher{}
+
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.AuthDisabled(), middleware.Tenant(services.NewTenantService(tenantRepo)))
+	song.NewHandler(svc, enricher, config.Default().API, config.Default().RateLimit).Register(r)
+	return r, enricher
+}
+
+func do(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func TestAddAndGetSong(t *testing.T) {
+	r, enricher := newRouter(t)
+
+	w := do(r, http.MethodPost, "/api/v1/songs", `{"group":" Muse ","song":"Uprising"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, body %s", w.Code, w.Body)
+	}
+	created := decode[dto.ResponseMessageWithData](t, w)
+	if created.Result.ID == 0 || created.Result.Group != "Muse" {
+		t.Errorf("created = %+v, want a saved song with a trimmed group", created.Result)
+	}
+	if len(enricher.songs) != 1 || enricher.songs[0].ID != created.Result.ID {
+		t.Errorf("enqueued = %+v, want the created song", enricher.songs)
+	}
+
+	w = do(r, http.MethodGet, "/api/v1/songs/"+strconv.Itoa(created.Result.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, body %s", w.Code, w.Body)
+	}
+	if got := decode[dto.SongResponse](t, w); got.Song != "Uprising" {
+		t.Errorf("song = %q, want Uprising", got.Song)
+	}
+	// Дата ещё не известна: null, как в выгрузке, а не 0001-01-01.
+	if !strings.Contains(w.Body.String(), `"releaseDate":null`) {
+		t.Errorf("body = %s, want a null releaseDate", w.Body)
+	}
+}
+
+func TestAddSongRejectsBadInput(t *testing.T) {
+	r, enricher := newRouter(t)
+
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"missing song", `{"group":"Muse"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"group":"Muse","song":"Uprising","text":"x"}`, http.StatusBadRequest},
+		{"malformed", `{"group":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := do(r, http.MethodPost, "/api/v1/songs", tt.body)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d, body %s", tt.name, w.Code, tt.want, w.Body)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+			t.Errorf("%s: Content-Type = %q, want application/problem+json", tt.name, ct)
+		}
+	}
+	if len(enricher.songs) != 0 {
+		t.Errorf("enqueued %d songs for rejected requests", len(enricher.songs))
+	}
+}
+
+func TestGetSongErrors(t *testing.T) {
+	r, _ := newRouter(t)
+
+	if w := do(r, http.MethodGet, "/api/v1/songs/abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bad id: status = %d, want 400", w.Code)
+	}
+	w := do(r, http.MethodGet, "/api/v1/songs/42", "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing song: status = %d, want 404", w.Code)
+	}
+	if p := decode[dto.Problem](t, w); p.Status != http.StatusNotFound {
+		t.Errorf("problem status = %d, want 404", p.Status)
+	}
+}
+
+func TestUpdateAndDeleteSong(t *testing.T) {
+	r, _ := newRouter(t)
+
+	created := decode[dto.ResponseMessageWithData](t, do(r, http.MethodPost, "/api/v1/songs", `{"group":"Muse","song":"Uprising"}`))
+	path := "/api/v1/songs/" + strconv.Itoa(created.Result.ID)
+
+	w := do(r, http.MethodPatch, path, `{"group":"Muse","song":"Starlight"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, body %s", w.Code, w.Body)
+	}
+	if got := decode[dto.ResponseMessageWithData](t, w); got.Result.Song != "Starlight" {
+		t.Errorf("updated song = %q, want Starlight", got.Result.Song)
+	}
+
+	if w := do(r, http.MethodDelete, path, ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET after delete: status = %d, want 404", w.Code)
+	}
+}
+
+func TestGetSongsAndVerses(t *testing.T) {
+	r, _ := newRouter(t)
+
+	for _, body := range []string{`{"group":"Muse","song":"Uprising"}`, `{"group":"Queen","song":"Bohemian Rhapsody"}`} {
+		if w := do(r, http.MethodPost, "/api/v1/songs", body); w.Code != http.StatusCreated {
+			t.Fatalf("POST status = %d, body %s", w.Code, w.Body)
+		}
+	}
+
+	w := do(r, http.MethodGet, "/api/v1/songs?group=Muse", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, body %s", w.Code, w.Body)
+	}
+	songs := decode[[]dto.SongResponse](t, w)
+	if len(songs) != 1 || songs[0].Group != "Muse" {
+		t.Errorf("songs = %+v, want only Muse", songs)
+	}
+
+	w = do(r, http.MethodGet, "/api/v1/songs/"+strconv.Itoa(songs[0].ID)+"/verses", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("verses status = %d, body %s", w.Code, w.Body)
+	}
+	if verses := decode[[]string](t, w); len(verses) != 0 {
+		t.Errorf("verses = %q, want none before enrichment", verses)
+	}
+}
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, http.MethodGet, "/verse/7", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v1/songs/7/verses>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+	if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") == "" {
+		t.Errorf("missing Deprecation or Sunset header: %v", w.Header())
+	}
+}
+
+// failingExport отдаёт rows песен и падает.
+type failingExport struct {
+	domain.SongService
+	rows int
+}
+
+func (s failingExport) ExportSongs(ctx context.Context, filter domain.SongFilter, fn func(*domain.Song) error) error {
+	for i := range s.rows {
+		if err := fn(&domain.Song{ID: i + 1, Group: "Muse", Song: "Uprising"}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection lost")
+}
+
+func newExportServer(t *testing.T, svc domain.SongService) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Recovery(), middleware.Errors(), middleware.AuthDisabled(), middleware.Tenant(nil))
+	song.NewHandler(svc, &stubEnricher{}, config.Default().API, config.Default().RateLimit).Register(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExportFailsBeforeFirstRow(t *testing.T) {
+	srv := newExportServer(t, failingExport{})
+
+	resp, err := http.Get(srv.URL + "/api/v1/songs/export?gzip=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q on an error response", enc)
+	}
+}
+
+func TestExportFailsMidStream(t *testing.T) {
+	for _, query := range []string{"", "?gzip=true"} {
+		srv := newExportServer(t, failingExport{rows: 1200})
+
+		resp, err := http.Get(srv.URL + "/api/v1/songs/export" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// http.Get сам распаковывает gzip и проверяет трейлер.
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%q: status = %d, want 200 already sent", query, resp.StatusCode)
+		}
+		if err == nil {
+			t.Errorf("%q: body read without error, want a broken stream", query)
+		}
+	}
+}
+
+func TestExportEmpty(t *testing.T) {
+	r, _ := newRouter(t)
+
+	w := do(r, http.MethodGet, "/api/v1/songs/export?format=json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != "[]" {
+		t.Errorf("body = %q, want []", got)
+	}
+}
+
+func TestAddDuplicateSong(t *testing.T) {
+	r, enricher := newRouter(t)
+
+	body := `{"group":"Muse","song":"Uprising"}`
+	if w := do(r, http.MethodPost, "/api/v1/songs", body); w.Code != http.StatusCreated {
+		t.Fatalf("first POST status = %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodPost, "/api/v1/songs", body); w.Code != http.StatusConflict {
+		t.Errorf("second POST status = %d, want 409, body %s", w.Code, w.Body)
+	}
+	if len(enricher.songs) != 1 {
+		t.Errorf("enqueued %d songs, want 1", len(enricher.songs))
+	}
+}
+
+// Уникальность песни держит и индекс БД: переименование в уже занятое
+// название сервис заранее не проверяет.
+func TestDuplicateSongConflictsInDatabase(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.Path = ":memory:"
+	gdb, err := db.InitDB(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	migrator, err := db.NewMigrator(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := newRouterWithRepo(t, repository.NewSongRepo(gdb))
+
+	body := `{"group":"Muse","song":"Uprising"}`
+	if w := do(r, http.MethodPost, "/api/v1/songs", body); w.Code != http.StatusCreated {
+		t.Fatalf("first POST status = %d, body %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodPost, "/api/v1/songs", body); w.Code != http.StatusConflict {
+		t.Errorf("second POST status = %d, want 409, body %s", w.Code, w.Body)
+	}
+
+	other := decode[dto.ResponseMessageWithData](t, do(r, http.MethodPost, "/api/v1/songs", `{"group":"Muse","song":"Starlight"}`))
+	w := do(r, http.MethodPatch, "/api/v1/songs/"+strconv.Itoa(other.Result.ID), body)
+	if w.Code != http.StatusConflict {
+		t.Errorf("PATCH to a taken title: status = %d, want 409, body %s", w.Code, w.Body)
+	}
+	if p := decode[dto.Problem](t, w); p.Status != http.StatusConflict {
+		t.Errorf("problem status = %d, want 409", p.Status)
+	}
+}
+
+func TestBulkAddSongsEnqueuesCreated(t *testing.T) {
+	r, enricher := newRouter(t)
+
+	body := `[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Uprising"},{"group":"Muse"}]`
+	w := do(r, http.MethodPost, "/api/v1/songs/bulk", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	resp := decode[dto.BulkResponse](t, w)
+	if resp.Created != 1 || resp.Duplicates != 1 || resp.Invalid != 1 {
+		t.Errorf("created/duplicates/invalid = %d/%d/%d, want 1/1/1", resp.Created, resp.Duplicates, resp.Invalid)
+	}
+	if resp.Enqueued != 1 || len(enricher.songs) != 1 {
+		t.Errorf("enqueued = %d (stub saw %d), want 1", resp.Enqueued, len(enricher.songs))
+	}
+}
+
+// fullQueue - очередь обогащения без свободного места.
+type fullQueue struct {
+	mu           sync.Mutex
+	waits, tries int
+}
+
+func (q *fullQueue) Enqueue(ctx context.Context, s domain.Song) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tries++
+	return false
+}
+
+func (q *fullQueue) EnqueueWait(ctx context.Context, s domain.Song) bool {
+	q.mu.Lock()
+	q.waits++
+	q.mu.Unlock()
+	<-ctx.Done()
+	return false
+}
+
+func TestBulkAddSongsOutlivesServerTimeouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenantRepo := repository.NewMemoryTenantRepo()
+	svc := services.NewSongService(repository.NewMemorySongRepo(), tenantRepo)
+	queue := &fullQueue{}
+
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.AuthDisabled(), middleware.Tenant(nil))
+	song.NewHandler(svc, queue, config.Default().API, config.Default().RateLimit).Register(r)
+	// Ожидание очереди дольше WriteTimeout: без продления ответ потеряется.
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 20 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	body := `[{"group":"Muse","song":"Uprising"},{"group":"Muse","song":"Starlight"},{"group":"Muse","song":"Hysteria"}]`
+	resp, err := http.Post(srv.URL+"/api/v1/songs/bulk", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("response lost: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got dto.BulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || got.Created != 3 || got.Enqueued != 0 {
+		t.Errorf("status %d, created %d, enqueued %d, want 200, 3, 0", resp.StatusCode, got.Created, got.Enqueued)
+	}
+	// Ждёт места только первая песня, остальные не держат запрос.
+	if queue.waits != 1 || queue.tries != 2 {
+		t.Errorf("waited %d times and tried %d times, want 1 and 2", queue.waits, queue.tries)
+	}
+}
+
+// tenantUsers выдаёт редактора арендатора, чьё имя передано вместо токена.
+type tenantUsers struct{}
+
+func (tenantUsers) Verify(ctx context.Context, token string) (*domain.Principal, error) {
+	tenant, _, _ := strings.Cut(token, ".")
+	return &domain.Principal{
+		Subject: "editor@" + tenant,
+		Tenant:  tenant,
+		Scopes:  []domain.Scope{domain.ScopeSongsRead, domain.ScopeSongsWrite},
+	}, nil
+}
+
+func TestTenantsCannotSeeEachOther(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	tenantRepo := repository.NewMemoryTenantRepo()
+	tenants := services.NewTenantService(tenantRepo)
+	for _, id := range []string{"label-a", "label-b"} {
+		if err := tenants.CreateTenant(ctx, &domain.Tenant{ID: id, Name: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := services.NewSongService(repository.NewMemorySongRepo(), tenantRepo)
+
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.Authenticate(nil, tenantUsers{}), middleware.Tenant(tenants))
+	song.NewHandler(svc, &stubEnricher{}, config.Default().API, config.Default().RateLimit).Register(r)
+
+	as := func(tenant, method, target, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tenant+".jwt.token")
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := as("label-a", http.MethodPost, "/api/v1/songs", `{"group":"Muse","song":"Uprising"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, body %s", w.Code, w.Body)
+	}
+	path := "/api/v1/songs/" + strconv.Itoa(decode[dto.ResponseMessageWithData](t, w).Result.ID)
+
+	tests := []struct {
+		name, method, target, body string
+		header                     []string
+		want                       int
+	}{
+		{"get", http.MethodGet, path, "", nil, http.StatusNotFound},
+		{"verses", http.MethodGet, path + "/verses", "", nil, http.StatusNotFound},
+		{"update", http.MethodPatch, path, `{"group":"Muse","song":"Starlight"}`, nil, http.StatusNotFound},
+		{"delete", http.MethodDelete, path, "", nil, http.StatusNotFound},
+		{"other tenant header", http.MethodGet, path, "", []string{middleware.TenantHeader, "label-a"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := as("label-b", tt.method, tt.target, tt.body, tt.header...); w.Code != tt.want {
+			t.Errorf("label-b %s: status = %d, want %d, body %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+
+	if got := decode[[]dto.SongResponse](t, as("label-b", http.MethodGet, "/api/v1/songs", "")); len(got) != 0 {
+		t.Errorf("label-b sees %d songs, want none", len(got))
+	}
+	if w := as("label-b", http.MethodGet, "/api/v1/songs/export", ""); strings.Contains(w.Body.String(), "Uprising") {
+		t.Errorf("label-b export contains label-a's song: %s", w.Body)
+	}
+
+	// Песня label-a не изменилась.
+	w = as("label-a", http.MethodGet, path, "")
+	if w.Code != http.StatusOK || decode[dto.SongResponse](t, w).Song != "Uprising" {
+		t.Errorf("label-a GET: status = %d, body %s, want the untouched song", w.Code, w.Body)
+	}
+}
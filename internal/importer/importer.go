@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"context"
+	"sort"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/validation"
+	"test-task/pkg/logging"
+)
+
+// Importer загружает песни из файлов, пропуская дубликаты и невалидные строки.
+type Importer struct {
+	songService domain.SongService
+	enricher    domain.SongEnricher
+	log         logging.Logger
+}
+
+// NewImporter создаёт импортёр. Если enricher не nil, новые песни
+// без текста ставятся в очередь на обогащение из внешнего API; при
+// заполненной очереди импорт ждёт, а не пропускает песни.
+func NewImporter(songService domain.SongService, enricher domain.SongEnricher) *Importer {
+	return &Importer{
+		songService: songService,
+		enricher:    enricher,
+		log:         logging.GetLogger(),
+	}
+}
+
+// Issue - строка файла, которая не была (или не будет) импортирована.
+type Issue struct {
+	Line   int
+	Status domain.ImportStatus
+	Reason string
+}
+
+type Report struct {
+	File       string
+	DryRun     bool
+	Total      int
+	Created    int
+	Duplicates int
+	Invalid    int
+	Skipped    int
+	Enqueued   int
+	Issues     []Issue
+}
+
+// Import читает файл и сохраняет новые песни. В режиме dryRun
+// ничего не сохраняется, отчёт показывает, что было бы сделано.
+func (im *Importer) Import(ctx context.Context, path string, opts Options, dryRun bool) (*Report, error) {
+	records, err := ReadFile(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{File: path, DryRun: dryRun, Total: len(records)}
+
+	songs := make([]*domain.Song, 0, len(records))
+	lines := make([]int, 0, len(records))
+	for _, rec := range records {
+		song, err := toSong(rec, opts.DateLayout)
+		if err != nil {
+			report.Invalid++
+			report.Issues = append(report.Issues, Issue{Line: rec.Line, Status: domain.ImportInvalid, Reason: err.Error()})
+			continue
+		}
+		songs = append(songs, song)
+		lines = append(lines, rec.Line)
+	}
+
+	results, err := im.songService.ImportSongs(ctx, songs, domain.ImportOptions{DryRun: dryRun})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, res := range results {
+		switch res.Status {
+		case domain.ImportCreated:
+			report.Created++
+			if !dryRun && im.enricher != nil && res.Song.Text == "" && im.enricher.EnqueueWait(ctx, *res.Song) {
+				report.Enqueued++
+			}
+			continue
+		case domain.ImportDuplicate:
+			report.Duplicates++
+		default:
+			report.Skipped++
+		}
+		report.Issues = append(report.Issues, Issue{Line: lines[i], Status: res.Status, Reason: res.Reason})
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Line < report.Issues[j].Line
+	})
+
+	im.log.Infof("import %s: %d created, %d duplicates, %d invalid, %d skipped (dry run: %t)",
+		path, report.Created, report.Duplicates, report.Invalid, report.Skipped, dryRun)
+	return report, nil
+}
+
+// toSong проверяет запись теми же правилами, что и POST /song.
+func toSong(rec Record, dateLayout string) (*domain.Song, error) {
+	req := dto.CreateSongRequest{
+		Group: rec.Group,
+		Song:  rec.Song,
+		Link:  rec.Link,
+	}
+
+	if rec.ReleaseDate != "" {
+		date, err := parseDate(rec.ReleaseDate, dateLayout)
+		if err != nil {
+			return nil, err
+		}
+		req.ReleaseDate = date
+	}
+
+	if err := validation.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	return &domain.Song{
+		Group:       req.Group,
+		Song:        req.Song,
+		Text:        rec.Text,
+		ReleaseDate: req.ReleaseDate,
+		Link:        req.Link,
+	}, nil
+}
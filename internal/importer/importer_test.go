@@ -0,0 +1,114 @@
+package importer_test
+
+import (
+	"context"
+	"reflect"
+	"test-task/internal/domain"
+	"test-task/internal/importer"
+	"test-task/internal/repository"
+	"test-task/internal/services"
+	"testing"
+)
+
+// queue запоминает песни, поставленные на обогащение.
+type queue struct {
+	songs []domain.Song
+}
+
+func (q *queue) Enqueue(ctx context.Context, s domain.Song) bool {
+	q.songs = append(q.songs, s)
+	return true
+}
+
+func (q *queue) EnqueueWait(ctx context.Context, s domain.Song) bool {
+	return q.Enqueue(ctx, s)
+}
+
+const songsCSV = `artist,title,lyrics,date,url
+Muse,Uprising,,2009-09-07,
+Muse,Starlight,"one
+two",,
+Muse,,,,
+Muse,Hysteria,,someday,
+Muse,Uprising,,,
+Muse,Resistance,,,not a link
+`
+
+func TestImport(t *testing.T) {
+	ctx := domain.WithActor(domain.WithTenant(context.Background(), domain.DefaultTenant), "importer")
+	svc := services.NewSongService(repository.NewMemorySongRepo(), repository.NewMemoryTenantRepo())
+	path := writeFile(t, "songs.csv", songsCSV)
+	opts := importer.Options{Mapping: byName}
+
+	wantIssues := []struct {
+		line   int
+		status domain.ImportStatus
+	}{
+		{5, domain.ImportInvalid},   // нет названия
+		{6, domain.ImportInvalid},   // дата не разобрана
+		{7, domain.ImportDuplicate}, // повтор строки 2
+		{8, domain.ImportInvalid},   // ссылка не URL
+	}
+	check := func(t *testing.T, report *importer.Report) {
+		t.Helper()
+		if report.Total != 6 || report.Created != 2 || report.Duplicates != 1 || report.Invalid != 3 {
+			t.Errorf("total/created/duplicates/invalid = %d/%d/%d/%d, want 6/2/1/3",
+				report.Total, report.Created, report.Duplicates, report.Invalid)
+		}
+		if len(report.Issues) != len(wantIssues) {
+			t.Fatalf("issues = %+v, want %d", report.Issues, len(wantIssues))
+		}
+		for i, want := range wantIssues {
+			if got := report.Issues[i]; got.Line != want.line || got.Status != want.status {
+				t.Errorf("issue %d = line %d %s (%s), want line %d %s", i, got.Line, got.Status, got.Reason, want.line, want.status)
+			}
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		q := &queue{}
+		report, err := importer.NewImporter(svc, q).Import(ctx, path, opts, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, report)
+		if n, _ := svc.CountSongs(ctx, domain.SongFilter{}); n != 0 || len(q.songs) != 0 {
+			t.Errorf("dry run saved %d songs and queued %d, want none", n, len(q.songs))
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		q := &queue{}
+		report, err := importer.NewImporter(svc, q).Import(ctx, path, opts, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, report)
+
+		songs, err := svc.GetSongs(ctx, "", "", 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, s := range songs {
+			titles = append(titles, s.Song)
+		}
+		if !reflect.DeepEqual(titles, []string{"Uprising", "Starlight"}) {
+			t.Errorf("saved %q, want Uprising and Starlight", titles)
+		}
+		// Песня с текстом в обогащении не нуждается.
+		if report.Enqueued != 1 || len(q.songs) != 1 || q.songs[0].Song != "Uprising" {
+			t.Errorf("enqueued %d (%+v), want only Uprising", report.Enqueued, q.songs)
+		}
+	})
+
+	t.Run("again", func(t *testing.T) {
+		report, err := importer.NewImporter(svc, nil).Import(ctx, path, opts, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Created != 0 || report.Duplicates != 3 {
+			t.Errorf("created/duplicates = %d/%d, want 0/3", report.Created, report.Duplicates)
+		}
+	})
+}
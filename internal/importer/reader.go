@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mapping задаёт, из каких колонок CSV (или ключей JSON) брать поля песни.
+// Пустое значение означает, что поле в файле отсутствует. Для CSV без
+// заголовка колонки указываются номерами, начиная с 1.
+type Mapping struct {
+	Group       string
+	Song        string
+	Text        string
+	ReleaseDate string
+	Link        string
+}
+
+type Options struct {
+	Format     string // csv или json; пусто - по расширению файла
+	Mapping    Mapping
+	Delimiter  rune
+	NoHeader   bool
+	DateLayout string
+}
+
+// Форматы даты, которые пробуются после Options.DateLayout.
+var dateLayouts = []string{time.RFC3339, time.DateOnly, "02.01.2006"}
+
+// Record - одна строка файла до проверки.
+type Record struct {
+	// Line - строка CSV, где начинается запись, или номер объекта в JSON.
+	Line        int
+	Group       string
+	Song        string
+	Text        string
+	ReleaseDate string
+	Link        string
+}
+
+// ReadFile читает записи из CSV- или JSON-файла согласно opts.
+func ReadFile(path string, opts Options) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	format := opts.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	switch format {
+	case "csv":
+		return readCSV(f, opts)
+	case "json":
+		return readJSON(f, opts.Mapping)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, use csv or json", format)
+	}
+}
+
+func readCSV(r io.Reader, opts Options) ([]Record, error) {
+	cr := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	cr.FieldsPerRecord = -1
+
+	columns := make(map[string]int)
+	if !opts.NoHeader {
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV header: %w", err)
+		}
+		for i, name := range header {
+			columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+		}
+	}
+
+	idx, err := opts.Mapping.resolve(columns, opts.NoHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// csv.ParseError сам называет строку файла.
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		// Номер строки файла, а не записи: поле в кавычках может
+		// занимать несколько строк.
+		line, _ := cr.FieldPos(0)
+
+		cell := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return row[i]
+		}
+		records = append(records, Record{
+			Line:        line,
+			Group:       cell(idx.group),
+			Song:        cell(idx.song),
+			Text:        cell(idx.text),
+			ReleaseDate: cell(idx.releaseDate),
+			Link:        cell(idx.link),
+		})
+	}
+	return records, nil
+}
+
+type columnIndex struct {
+	group, song, text, releaseDate, link int
+}
+
+// resolve переводит названия (или номера) колонок в индексы.
+// Обязательны только колонки группы и песни.
+func (m Mapping) resolve(columns map[string]int, byNumber bool) (columnIndex, error) {
+	find := func(name string, required bool) (int, error) {
+		if name == "" {
+			if required {
+				return -1, fmt.Errorf("column mapping is required")
+			}
+			return -1, nil
+		}
+		if byNumber {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 1 {
+				return -1, fmt.Errorf("column %q must be a 1-based number when the file has no header", name)
+			}
+			return n - 1, nil
+		}
+		i, ok := columns[name]
+		if !ok {
+			if required {
+				return -1, fmt.Errorf("column %q not found in CSV header", name)
+			}
+			return -1, nil
+		}
+		return i, nil
+	}
+
+	var (
+		idx columnIndex
+		err error
+	)
+	if idx.group, err = find(m.Group, true); err != nil {
+		return idx, fmt.Errorf("group: %w", err)
+	}
+	if idx.song, err = find(m.Song, true); err != nil {
+		return idx, fmt.Errorf("song: %w", err)
+	}
+	if idx.text, err = find(m.Text, false); err != nil {
+		return idx, fmt.Errorf("text: %w", err)
+	}
+	if idx.releaseDate, err = find(m.ReleaseDate, false); err != nil {
+		return idx, fmt.Errorf("release date: %w", err)
+	}
+	if idx.link, err = find(m.Link, false); err != nil {
+		return idx, fmt.Errorf("link: %w", err)
+	}
+	return idx, nil
+}
+
+func readJSON(r io.Reader, m Mapping) ([]Record, error) {
+	var rows []map[string]any
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("reading JSON: expected an array of objects: %w", err)
+	}
+
+	field := func(row map[string]any, key string) string {
+		if key == "" {
+			return ""
+		}
+		switch v := row[key].(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+
+	records := make([]Record, 0, len(rows))
+	for i, row := range rows {
+		records = append(records, Record{
+			Line:        i + 1,
+			Group:       field(row, m.Group),
+			Song:        field(row, m.Song),
+			Text:        field(row, m.Text),
+			ReleaseDate: field(row, m.ReleaseDate),
+			Link:        field(row, m.Link),
+		})
+	}
+	return records, nil
+}
+
+func parseDate(value, layout string) (time.Time, error) {
+	layouts := dateLayouts
+	if layout != "" {
+		layouts = append([]string{layout}, dateLayouts...)
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
@@ -0,0 +1,115 @@
+package importer_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"test-task/internal/importer"
+	"testing"
+)
+
+// writeFile кладёт content во временный файл name.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var byName = importer.Mapping{Group: "artist", Song: "title", Text: "lyrics", ReleaseDate: "date", Link: "url"}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    importer.Options
+		want    []importer.Record
+	}{
+		{
+			name:    "header in any order",
+			content: "\ufefftitle,artist,date\nUprising,Muse,2009-09-07\nStarlight,Muse,\n",
+			opts:    importer.Options{Mapping: byName},
+			want: []importer.Record{
+				{Line: 2, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07"},
+				{Line: 3, Group: "Muse", Song: "Starlight"},
+			},
+		},
+		{
+			name:    "multi-line field",
+			content: "artist,title,lyrics\nMuse,Uprising,\"one\ntwo\nthree\"\nMuse,Starlight,\n",
+			opts:    importer.Options{Mapping: byName},
+			want: []importer.Record{
+				{Line: 2, Group: "Muse", Song: "Uprising", Text: "one\ntwo\nthree"},
+				{Line: 5, Group: "Muse", Song: "Starlight"},
+			},
+		},
+		{
+			name:    "no header, columns by number",
+			content: "Uprising;Muse\nStarlight;Muse\n",
+			opts:    importer.Options{NoHeader: true, Delimiter: ';', Mapping: importer.Mapping{Group: "2", Song: "1"}},
+			want: []importer.Record{
+				{Line: 1, Group: "Muse", Song: "Uprising"},
+				{Line: 2, Group: "Muse", Song: "Starlight"},
+			},
+		},
+		{
+			name:    "short row",
+			content: "artist,title,url\nMuse\n",
+			opts:    importer.Options{Mapping: byName},
+			want:    []importer.Record{{Line: 2, Group: "Muse"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importer.ReadFile(writeFile(t, "songs.csv", tt.content), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		opts                importer.Options
+		wantErr             string
+	}{
+		{"missing column", "songs.csv", "artist,name\nMuse,Uprising\n", importer.Options{Mapping: byName}, `column "title" not found`},
+		{"no mapping", "songs.csv", "artist,title\n", importer.Options{Mapping: importer.Mapping{Song: "title"}}, "group: column mapping is required"},
+		{"column name without header", "songs.csv", "Muse,Uprising\n", importer.Options{NoHeader: true, Mapping: byName}, "must be a 1-based number"},
+		// Строка ошибки - строка файла, а не номер записи.
+		{"broken quotes", "songs.csv", "artist,title,lyrics\nMuse,Uprising,\"one\ntwo\"\nMuse,\"Star\"light,\n", importer.Options{Mapping: byName}, "line 4"},
+		{"JSON object instead of array", "songs.json", `{"artist":"Muse"}`, importer.Options{Mapping: byName}, "expected an array"},
+		{"unknown format", "songs.xml", "<songs/>", importer.Options{Mapping: byName}, "unsupported format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importer.ReadFile(writeFile(t, tt.file, tt.content), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadFile() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	content := `[{"artist":"Muse","title":"Uprising","date":"2009-09-07","year":2009},{"artist":"Muse","title":null}]`
+	got, err := importer.ReadFile(writeFile(t, "songs.json", content), importer.Options{Mapping: byName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []importer.Record{
+		{Line: 1, Group: "Muse", Song: "Uprising", ReleaseDate: "2009-09-07"},
+		{Line: 2, Group: "Muse"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v, want %+v", got, want)
+	}
+}
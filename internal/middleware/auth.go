@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	APIKeyHeader    = "X-API-Key"
+	principalCtxKey = "principal"
+	authDisabledKey = "auth_disabled"
+)
+
+// Authenticate проверяет учётные данные из заголовка Authorization: Bearer
+// или X-API-Key и сохраняет вызывающего в контексте gin. Это API-ключ или,
+// если настроен users, JWT пользователя. Запрос без учётных данных идёт
+// дальше: нужны ли они, решает RequireScope маршрута. Неверные - сразу 401.
+// keys равен nil в демо-режиме, users - если JWT не настроены.
+func Authenticate(keys domain.APIKeyService, users domain.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := credentials(c)
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		var principal *domain.Principal
+		switch {
+		// В API-ключе точек нет, а JWT - это три части через точку.
+		case users != nil && strings.Count(token, ".") == 2:
+			p, err := users.Verify(ctx, token)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			principal = p
+		case keys != nil:
+			key, err := keys.Authenticate(ctx, token)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			principal = key.Principal()
+		default:
+			c.Error(fmt.Errorf("%w: API keys are not available without a database, use a user token", domain.ErrUnauthorized))
+			c.Abort()
+			return
+		}
+
+		c.Set(principalCtxKey, principal)
+		log := logging.FromContext(ctx)
+		ctx = logging.WithContext(ctx, log.GetLoggerWithField("actor", principal.Subject))
+		c.Request = c.Request.WithContext(domain.WithActor(ctx, principal.Subject))
+		c.Next()
+	}
+}
+
+// AuthDisabled открывает все маршруты. Только для локальной разработки.
+func AuthDisabled() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(authDisabledKey, true)
+		c.Next()
+	}
+}
+
+// RequireScope пускает к маршруту только запросы, у которых есть право
+// scope: без учётных данных - 401, без права - 403.
+func RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool(authDisabledKey) {
+			c.Next()
+			return
+		}
+
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.Error(fmt.Errorf("%w: credentials required, pass an API key or a user token as Authorization: Bearer <token>, or an API key as %s", domain.ErrUnauthorized, APIKeyHeader))
+			c.Abort()
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.Error(fmt.Errorf("%w: %s lacks the %s scope", domain.ErrForbidden, principal.Subject, scope))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireGlobal пускает к маршруту только вызывающих, не привязанных
+// к арендатору: настройки установки общие для всех арендаторов, и ключ
+// лейбла не должен их менять даже с правом admin. Ставится после
+// RequireScope.
+func RequireGlobal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal := GetPrincipal(c); principal != nil && principal.Tenant != "" {
+			c.Error(fmt.Errorf("%w: %s belongs to tenant %s, the route needs credentials without a tenant", domain.ErrForbidden, principal.Subject, principal.Tenant))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal возвращает вызывающего текущего запроса или nil.
+func GetPrincipal(c *gin.Context) *domain.Principal {
+	v, _ := c.Get(principalCtxKey)
+	p, _ := v.(*domain.Principal)
+	return p
+}
+
+func credentials(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(c.GetHeader(APIKeyHeader))
+}
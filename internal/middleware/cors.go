@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"test-task/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовки ответа, которые браузер покажет скрипту с другого домена.
+var exposedHeaders = []string{
+	RequestIDHeader,
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After",
+	"Content-Disposition", "WWW-Authenticate",
+	"Deprecation", "Sunset", "Link",
+}
+
+// CORS разрешает запросы из браузера с доменов cfg.Origins. Preflight
+// (OPTIONS с Access-Control-Request-Method) получает ответ здесь же и до
+// проверки ключа не доходит: браузер отправляет его без учётных данных.
+// Запросы с других доменов проходят без заголовков CORS, и их ответ браузер
+// скрипту не отдаст; preflight от них получает 403.
+func CORS(cfg config.CORS) gin.HandlerFunc {
+	origins := cfg.AllowedOrigins()
+	anyOrigin := slices.Contains(origins, "*")
+	methods := strings.Join(cfg.AllowedMethods(), ", ")
+	headers := strings.Join(cfg.AllowedHeaders(), ", ")
+	exposed := strings.Join(exposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		return anyOrigin || slices.ContainsFunc(origins, func(o string) bool {
+			return strings.EqualFold(strings.TrimSuffix(o, "/"), origin)
+		})
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origins) == 0 || origin == "" {
+			c.Next()
+			return
+		}
+
+		// Ответ зависит от Origin, кеши не должны отдавать его другому домену.
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !cfg.Credentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.Credentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			c.Header("Access-Control-Expose-Headers", exposed)
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", methods)
+		c.Header("Access-Control-Allow-Headers", headers)
+		c.Header("Access-Control-Max-Age", maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
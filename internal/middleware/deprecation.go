@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated помечает устаревший маршрут: Deprecation (RFC 9745) с датой,
+// когда он устарел, Sunset (RFC 8594) с датой отключения и Link на маршрут,
+// которым его заменить. Параметры пути (:id) в successor подставляются
+// из запроса.
+func Deprecated(successor string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		link := successor
+		for _, p := range c.Params {
+			link = strings.ReplaceAll(link, ":"+p.Key, p.Value)
+		}
+
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetAt)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, link))
+		c.Next()
+	}
+}
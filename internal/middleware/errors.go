@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/validation"
+	"test-task/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+type problemKind struct {
+	err    error
+	status int
+	slug   string
+	title  string
+}
+
+// Соответствие ошибок domain HTTP-ответам. Порядок важен:
+// берётся первый вид, которому соответствует ошибка.
+var problemKinds = []problemKind{
+	{domain.ErrValidation, http.StatusUnprocessableEntity, "validation-error", "Request validation failed"},
+	// Раньше ErrInvalidInput: ошибка чтения тела оборачивает оба вида.
+	{domain.ErrTooLarge, http.StatusRequestEntityTooLarge, "too-large", "Request body too large"},
+	{domain.ErrInvalidInput, http.StatusBadRequest, "invalid-input", "Malformed request"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized", "Authentication required"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden", "Permission denied"},
+	{domain.ErrQuotaExceeded, http.StatusForbidden, "quota-exceeded", "Quota exceeded"},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, "rate-limited", "Too many requests"},
+	{domain.ErrNotFound, http.StatusNotFound, "not-found", "Resource not found"},
+	{domain.ErrConflict, http.StatusConflict, "conflict", "Resource already exists"},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "unavailable", "Service temporarily unavailable"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout", "Request timed out"},
+	// Клиент ушёл, не дождавшись ответа; код 499 увидят только логи и метрики.
+	{context.Canceled, statusClientClosedRequest, "canceled", "Client closed request"},
+}
+
+const statusClientClosedRequest = 499
+
+var internalKind = problemKind{
+	status: http.StatusInternalServerError,
+	slug:   "internal",
+	title:  "Internal server error",
+}
+
+// Errors превращает последнюю ошибку, добавленную обработчиком
+// через c.Error, в ответ application/problem+json.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		problem := NewProblem(c, err)
+
+		l := logging.FromContext(c.Request.Context())
+		if problem.Status >= http.StatusInternalServerError {
+			l.Error(err.Error())
+		} else {
+			l.Warn(err.Error())
+		}
+
+		if problem.Status == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer realm="song-library"`)
+		}
+		c.Header("Content-Type", problemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// NewProblem строит описание ошибки для ответа клиенту. Подробности
+// внутренних ошибок наружу не отдаются, они остаются только в логах.
+func NewProblem(c *gin.Context, err error) dto.Problem {
+	kind := internalKind
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			kind = k
+			break
+		}
+	}
+
+	problem := dto.Problem{
+		Type:      "/problems/" + kind.slug,
+		Title:     kind.title,
+		Status:    kind.status,
+		Instance:  c.Request.URL.Path,
+		RequestID: GetRequestID(c),
+	}
+
+	if kind.status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		problem.Detail = "one or more fields are invalid"
+		problem.Errors = verrs
+	}
+
+	return problem
+}
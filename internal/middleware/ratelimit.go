@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"test-task/internal/domain"
+	"test-task/pkg/metrics"
+	"test-task/pkg/ratelimit"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает частоту запросов одного клиента к группе
+// маршрутов name. Клиент - API-ключ или пользователь, без учётных
+// данных - IP-адрес. Ответ содержит заголовки RateLimit-Limit,
+// RateLimit-Remaining и RateLimit-Reset, а сверх лимита - 429 с Retry-After.
+// limiter равен nil, если ограничение выключено.
+func RateLimit(name string, limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		result := limiter.Allow(clientKey(c))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", seconds(result.Reset))
+
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			c.Header("Retry-After", seconds(result.RetryAfter))
+			c.Error(fmt.Errorf("%w: too many %s requests, retry in %s s", domain.ErrRateLimited, name, seconds(result.RetryAfter)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey - чей счётчик расходует запрос. Ключ и пользователь считаются
+// отдельно от адреса, чтобы клиенты за одним NAT не мешали друг другу.
+func clientKey(c *gin.Context) string {
+	if p := GetPrincipal(c); p != nil {
+		return p.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds округляет вверх: клиент, подождавший столько, точно успеет.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"test-task/internal/domain"
+	"test-task/internal/dto"
+	"test-task/internal/middleware"
+	"test-task/pkg/ratelimit"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubVerifier выдаёт пользователя с sub, равным токену.
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(ctx context.Context, token string) (*domain.Principal, error) {
+	return &domain.Principal{Subject: token, Scopes: []domain.Scope{domain.ScopeSongsRead}}, nil
+}
+
+func newLimitedRouter(burst int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.Authenticate(nil, stubVerifier{}))
+	// Скорость почти нулевая: за время теста ведро не наполняется.
+	r.GET("/songs", middleware.RateLimit("read", ratelimit.NewLimiter(0.001, burst)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+// get делает запрос от имени token (JWT-подобная строка) или, если
+// token пуст, анонимно с адреса addr.
+func get(r http.Handler, token, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	req.RemoteAddr = addr
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	r := newLimitedRouter(2)
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := get(r, "alice.jwt.token", "192.0.2.1:1234")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %s", i, got, wantRemaining)
+		}
+		if w.Header().Get("RateLimit-Reset") == "" {
+			t.Errorf("request %d: no RateLimit-Reset", i)
+		}
+		if got := w.Header().Get("Retry-After"); got != "" {
+			t.Errorf("request %d: Retry-After = %q on an allowed request", i, got)
+		}
+	}
+
+	w := get(r, "alice.jwt.token", "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the limit: status = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got == "" || got == "0" {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var problem dto.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusTooManyRequests || problem.Type != "/problems/rate-limited" {
+		t.Errorf("problem = %+v, want a rate-limited 429", problem)
+	}
+}
+
+func TestRateLimitBucketPerClient(t *testing.T) {
+	r := newLimitedRouter(1)
+
+	tests := []struct {
+		name, token, addr string
+		want              int
+	}{
+		{"alice", "alice.jwt.token", "192.0.2.1:1234", http.StatusOK},
+		{"alice again", "alice.jwt.token", "192.0.2.9:1234", http.StatusTooManyRequests},
+		// Тот же адрес, но другой пользователь - своё ведро.
+		{"bob", "bob.jwt.token", "192.0.2.1:1234", http.StatusOK},
+		{"anonymous", "", "192.0.2.1:1234", http.StatusOK},
+		{"anonymous again", "", "192.0.2.1:5678", http.StatusTooManyRequests},
+		{"anonymous elsewhere", "", "192.0.2.2:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := get(r, tt.token, tt.addr); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+	"test-task/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery превращает панику обработчика в ответ 500. Панику
+// http.ErrAbortHandler пропускает дальше: так обработчик обрывает уже
+// начатый ответ, и net/http молча закрывает соединение.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			logging.FromContext(c.Request.Context()).Errorf("panic recovered: %v\n%s", err, debug.Stack())
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"test-task/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+	maxRequestIDLen = 128
+)
+
+// RequestID берёт ID запроса из заголовка X-Request-ID или генерирует новый,
+// сохраняет его в контексте gin и возвращает клиенту в ответе. В контекст
+// запроса кладётся логгер с полем request_id, его достают через
+// logging.FromContext.
+func RequestID() gin.HandlerFunc {
+	log := logging.GetLogger()
+
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		l := log.GetLoggerWithField(requestIDKey, id)
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), l))
+		c.Next()
+	}
+}
+
+// GetRequestID возвращает ID текущего запроса или пустую строку.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID отсекает пустые, слишком длинные и непечатаемые ID,
+// чтобы клиент не мог подсунуть в логи произвольный мусор.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"test-task/internal/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerPath - страница Swagger UI подключает свои скрипты и стили,
+// строгая Content-Security-Policy API её бы сломала.
+const swaggerPath = "/swagger/"
+
+// SecurityHeaders добавляет стандартные заголовки защиты. API отдаёт только
+// JSON, поэтому ответу запрещено всё: исполнять скрипты, встраиваться во фреймы
+// и передавать Referer. hstsMaxAge больше нуля включает Strict-Transport-Security.
+func SecurityHeaders(hstsMaxAge time.Duration) gin.HandlerFunc {
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", int(hstsMaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "no-referrer")
+		if !strings.HasPrefix(c.Request.URL.Path, swaggerPath) {
+			h.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		}
+		if hstsMaxAge > 0 {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
+
+// BodyLimit ограничивает тело запроса limit байтами. Заявленное
+// в Content-Length большее тело отклоняется сразу, иначе чтение
+// прерывается на лимите ошибкой domain.ErrTooLarge (413).
+func BodyLimit(limit int64) gin.HandlerFunc {
+	tooLarge := fmt.Errorf("%w: limit is %d bytes", domain.ErrTooLarge, limit)
+
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.Error(tooLarge)
+			c.Abort()
+			return
+		}
+		c.Request.Body = &limitedBody{
+			ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit),
+			err:        tooLarge,
+		}
+		c.Next()
+	}
+}
+
+// limitedBody подменяет ошибку http.MaxBytesReader ошибкой domain,
+// чтобы её узнал обработчик ошибок.
+type limitedBody struct {
+	io.ReadCloser
+	err error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		err = b.err
+	}
+	return n, err
+}
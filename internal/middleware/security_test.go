@@ -0,0 +1,150 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test-task/internal/dto"
+	"test-task/internal/middleware"
+	"test-task/internal/validation"
+	"test-task/pkg/config"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default().CORS
+	cfg.Origins = "https://app.example.com"
+	cfg.MaxAge = 10 * time.Minute
+
+	r := gin.New()
+	r.Use(middleware.CORS(cfg))
+	r.GET("/songs", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSRouter()
+
+	tests := []struct {
+		origin     string
+		wantStatus int
+		wantOrigin string
+	}{
+		{"https://app.example.com", http.StatusNoContent, "https://app.example.com"},
+		{"https://evil.example.com", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/songs", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.origin, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.wantOrigin)
+		}
+		if !strings.Contains(w.Header().Get("Vary"), "Origin") {
+			t.Errorf("%s: Vary = %q, want Origin", tt.origin, w.Header().Get("Vary"))
+		}
+		if tt.wantStatus != http.StatusNoContent {
+			continue
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodPost) {
+			t.Errorf("Access-Control-Allow-Methods = %q, want POST", got)
+		}
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+		}
+	}
+}
+
+func TestCORSRequestFromOtherOrigin(t *testing.T) {
+	r := newCORSRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Запрос обрабатывается, но ответ браузер скрипту не отдаст.
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q for a foreign origin", got)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.SecurityHeaders(time.Hour))
+	r.GET("/songs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+	want := map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+		"Strict-Transport-Security": "max-age=3600; includeSubDomains",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestBodyLimitAndStrictJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.POST("/songs", middleware.BodyLimit(64), func(c *gin.Context) {
+		var req dto.CreateSongRequest
+		if err := validation.BindJSON(c, &req); err != nil {
+			c.Error(err)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	long := `{"group":"Muse","song":"` + strings.Repeat("a", 100) + `"}`
+	tests := []struct {
+		name    string
+		body    io.Reader
+		chunked bool
+		want    int
+	}{
+		{"valid", strings.NewReader(`{"group":"Muse","song":"Uprising"}`), false, http.StatusCreated},
+		{"too large", strings.NewReader(long), false, http.StatusRequestEntityTooLarge},
+		// Без Content-Length лимит срабатывает при чтении.
+		{"too large chunked", strings.NewReader(long), true, http.StatusRequestEntityTooLarge},
+		{"unknown field", strings.NewReader(`{"group":"Muse","song":"Uprising","album":"x"}`), false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/songs", tt.body)
+		req.Header.Set("Content-Type", "application/json")
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d, body %s", tt.name, w.Code, tt.want, w.Body)
+		}
+		if tt.want >= http.StatusBadRequest && w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: Content-Type = %q, want application/problem+json", tt.name, w.Header().Get("Content-Type"))
+		}
+	}
+}
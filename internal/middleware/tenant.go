@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const TenantHeader = "X-Tenant-ID"
+
+const (
+	// tenantMissTTL - сколько помнить, что арендатора нет: созданный
+	// командой tenant create станет доступен не позже.
+	tenantMissTTL = 30 * time.Second
+	// maxTenantMisses ограничивает память под несуществующие ID.
+	maxTenantMisses = 1024
+)
+
+// Tenant выбирает арендатора запроса и кладёт его в контекст: дальше
+// репозиторий видит только песни этого арендатора. Вызывающий, привязанный
+// к арендатору, работает только с ним. Непривязанный работает с арендатором
+// по умолчанию, а выбрать другой заголовком X-Tenant-ID может только
+// с правом admin или при выключенной проверке доступа. Должен стоять
+// после Authenticate или AuthDisabled. tenants равен nil в routes.
+//
+// Middleware стоит до лимитов частоты, поэтому результаты проверки
+// арендатора кешируются: отклонённые лимитом запросы не должны ходить в БД.
+func Tenant(tenants domain.TenantService) gin.HandlerFunc {
+	known := newTenantCache(tenants)
+	return func(c *gin.Context) {
+		requested := strings.TrimSpace(c.GetHeader(TenantHeader))
+		principal := GetPrincipal(c)
+
+		id := domain.DefaultTenant
+		switch {
+		case principal != nil && principal.Tenant != "":
+			if requested != "" && requested != principal.Tenant {
+				c.Error(fmt.Errorf("%w: %s belongs to tenant %s", domain.ErrForbidden, principal.Subject, principal.Tenant))
+				c.Abort()
+				return
+			}
+			id = principal.Tenant
+		case requested != "" && c.GetBool(authDisabledKey):
+			id = requested
+		case requested != "" && principal != nil:
+			if !principal.HasScope(domain.ScopeAdmin) {
+				c.Error(fmt.Errorf("%w: only admin credentials may choose a tenant with %s", domain.ErrForbidden, TenantHeader))
+				c.Abort()
+				return
+			}
+			id = requested
+		}
+		// Без учётных данных заголовок не учитывается: закрытый маршрут
+		// ответит 401 в RequireScope, открытому арендатор не нужен.
+
+		// Арендатор по умолчанию есть всегда, остальные проверяем: опечатка
+		// в заголовке или claim должна давать 404, а не пустой список.
+		if id != domain.DefaultTenant && tenants != nil {
+			if err := known.check(c.Request.Context(), id); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+
+		ctx := c.Request.Context()
+		log := logging.FromContext(ctx)
+		ctx = logging.WithContext(ctx, log.GetLoggerWithField("tenant", id))
+		c.Request = c.Request.WithContext(domain.WithTenant(ctx, id))
+		c.Next()
+	}
+}
+
+// tenantCache помнит, какие арендаторы есть. Арендаторы не удаляются,
+// поэтому найденный проверяется один раз, а отсутствующий - не чаще
+// раза в tenantMissTTL. Ошибки БД не кешируются.
+type tenantCache struct {
+	tenants domain.TenantService
+	now     func() time.Time
+
+	mu     sync.Mutex
+	found  map[string]struct{}
+	missed map[string]time.Time
+}
+
+func newTenantCache(tenants domain.TenantService) *tenantCache {
+	return &tenantCache{
+		tenants: tenants,
+		now:     time.Now,
+		found:   map[string]struct{}{},
+		missed:  map[string]time.Time{},
+	}
+}
+
+func (tc *tenantCache) check(ctx context.Context, id string) error {
+	tc.mu.Lock()
+	_, found := tc.found[id]
+	until, missed := tc.missed[id]
+	tc.mu.Unlock()
+	if found {
+		return nil
+	}
+	if missed && tc.now().Before(until) {
+		return fmt.Errorf("tenant %s %w", id, domain.ErrNotFound)
+	}
+
+	_, err := tc.tenants.GetTenant(ctx, id)
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	switch {
+	case err == nil:
+		tc.found[id] = struct{}{}
+		delete(tc.missed, id)
+	case errors.Is(err, domain.ErrNotFound):
+		if len(tc.missed) >= maxTenantMisses {
+			clear(tc.missed)
+		}
+		tc.missed[id] = tc.now().Add(tenantMissTTL)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"test-task/internal/domain"
+	"test-task/internal/middleware"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingTenants знает одного арендатора label-a и считает обращения.
+type countingTenants struct {
+	domain.TenantService
+
+	mu    sync.Mutex
+	calls map[string]int
+	down  bool
+}
+
+func (s *countingTenants) GetTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[id]++
+	switch {
+	case s.down:
+		return nil, errors.New("database is down")
+	case id == "label-a":
+		return &domain.Tenant{ID: id}, nil
+	}
+	return nil, fmt.Errorf("tenant %s %w", id, domain.ErrNotFound)
+}
+
+func TestTenantLookupsAreCached(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenants := &countingTenants{calls: map[string]int{}}
+	r := gin.New()
+	r.Use(middleware.Errors(), middleware.AuthDisabled(), middleware.Tenant(tenants))
+	r.GET("/songs", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(tenant string) int {
+		req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+		req.Header.Set(middleware.TenantHeader, tenant)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for range 3 {
+		if code := get("label-a"); code != http.StatusOK {
+			t.Errorf("label-a: status = %d, want 200", code)
+		}
+		if code := get("label-x"); code != http.StatusNotFound {
+			t.Errorf("label-x: status = %d, want 404", code)
+		}
+	}
+	if tenants.calls["label-a"] != 1 || tenants.calls["label-x"] != 1 {
+		t.Errorf("lookups = %v, want one per tenant", tenants.calls)
+	}
+
+	// Ошибку БД не запоминаем: следующий запрос спросит снова.
+	tenants.down = true
+	for range 2 {
+		if code := get("label-b"); code != http.StatusInternalServerError {
+			t.Errorf("label-b with the database down: status = %d, want 500", code)
+		}
+	}
+	if tenants.calls["label-b"] != 2 {
+		t.Errorf("label-b looked up %d times, want 2", tenants.calls["label-b"])
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// apiKeyModel - строка таблицы api_keys. Права хранятся одной строкой
+// через запятую, ключ без арендатора - с NULL в tenant_id.
+type apiKeyModel struct {
+	ID        int `gorm:"primaryKey;autoIncrement"`
+	Name      string
+	TenantID  *string
+	Prefix    string
+	Hash      string
+	Scopes    string
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+func (apiKeyModel) TableName() string {
+	return "api_keys"
+}
+
+func toAPIKeyModel(k *domain.APIKey) *apiKeyModel {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	var tenant *string
+	if k.TenantID != "" {
+		tenant = &k.TenantID
+	}
+	return &apiKeyModel{
+		ID:        k.ID,
+		Name:      k.Name,
+		TenantID:  tenant,
+		Prefix:    k.Prefix,
+		Hash:      k.Hash,
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: k.CreatedAt,
+		ExpiresAt: k.ExpiresAt,
+		RevokedAt: k.RevokedAt,
+	}
+}
+
+func (m *apiKeyModel) toDomain() domain.APIKey {
+	var scopes []domain.Scope
+	for _, s := range strings.Split(m.Scopes, ",") {
+		if s != "" {
+			scopes = append(scopes, domain.Scope(s))
+		}
+	}
+	var tenant string
+	if m.TenantID != nil {
+		tenant = *m.TenantID
+	}
+	return domain.APIKey{
+		ID:        m.ID,
+		Name:      m.Name,
+		TenantID:  tenant,
+		Prefix:    m.Prefix,
+		Hash:      m.Hash,
+		Scopes:    scopes,
+		CreatedAt: m.CreatedAt,
+		ExpiresAt: m.ExpiresAt,
+		RevokedAt: m.RevokedAt,
+	}
+}
+
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) domain.APIKeyRepository {
+	return &APIKeyRepo{db: db}
+}
+
+// Ключи всегда читаются из основной БД: отозванный ключ не должен
+// работать, пока изменение доходит до реплики.
+func (r *APIKeyRepo) query(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Clauses(dbresolver.Write)
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, key *domain.APIKey) error {
+	m := toAPIKeyModel(key)
+	if err := r.query(ctx).Create(m).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	key.ID = m.ID
+	return nil
+}
+
+func (r *APIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var m apiKeyModel
+	if err := r.query(ctx).Where("prefix = ?", prefix).First(&m).Error; err != nil {
+		return nil, translateError(err)
+	}
+	key := m.toDomain()
+	return &key, nil
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]domain.APIKey, error) {
+	var models []apiKeyModel
+	if err := r.query(ctx).Order("id").Find(&models).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, translateError(err)
+	}
+
+	keys := make([]domain.APIKey, len(models))
+	for i := range models {
+		keys[i] = models[i].toDomain()
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id int, at time.Time) error {
+	result := r.query(ctx).Model(&apiKeyModel{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if err := result.Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"test-task/internal/domain"
+)
+
+// MemorySongRepo хранит песни в памяти процесса. Ведёт себя так же,
+// как SongRepo: выдаёт возрастающие ID, возвращает domain.ErrNotFound
+// и domain.ErrConflict, фильтрует и листает песни в порядке ID и не
+// показывает песни другого арендатора.
+// Используется в тестах и в демо-режиме (DB_DRIVER=memory).
+type MemorySongRepo struct {
+	mu     sync.RWMutex
+	songs  map[int]domain.Song
+	nextID int
+}
+
+func NewMemorySongRepo() domain.SongRepository {
+	return &MemorySongRepo{
+		songs:  make(map[int]domain.Song),
+		nextID: 1,
+	}
+}
+
+func (r *MemorySongRepo) GetAll(ctx context.Context, group, song string, offset, limit int) ([]domain.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Как в SQL через gorm: отрицательный limit снимает ограничение.
+	matched, err := r.filter(ctx, domain.SongFilter{Group: group, Song: song})
+	if err != nil {
+		return nil, err
+	}
+	if offset >= len(matched) || limit == 0 {
+		return []domain.Song{}, nil
+	}
+	end := len(matched)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return matched[offset:end], nil
+}
+
+func (r *MemorySongRepo) GetByID(ctx context.Context, id int) (*domain.Song, error) {
+	tenant, all, err := readTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.songs[id]
+	if !ok || (!all && song.TenantID != tenant) {
+		return nil, domain.ErrNotFound
+	}
+	return &song, nil
+}
+
+func (r *MemorySongRepo) Delete(ctx context.Context, id int) error {
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if song, ok := r.songs[id]; !ok || song.TenantID != tenant {
+		return domain.ErrNotFound
+	}
+	delete(r.songs, id)
+	return nil
+}
+
+// UpdateTitle и UpdateInfo, как и у SongRepo, меняют только свои поля
+// существующей песни своего арендатора.
+func (r *MemorySongRepo) UpdateTitle(ctx context.Context, song *domain.Song) error {
+	return r.update(ctx, song, func(stored *domain.Song) error {
+		if song.Group != "" {
+			stored.Group = song.Group
+		}
+		if song.Song != "" {
+			stored.Song = song.Song
+		}
+		if err := r.checkKey(stored); err != nil {
+			return err
+		}
+		stored.UpdatedBy = song.UpdatedBy
+		*song = *stored
+		return nil
+	})
+}
+
+func (r *MemorySongRepo) UpdateInfo(ctx context.Context, song *domain.Song) error {
+	return r.update(ctx, song, func(stored *domain.Song) error {
+		stored.Text = song.Text
+		stored.ReleaseDate = song.ReleaseDate
+		stored.Link = song.Link
+		return nil
+	})
+}
+
+func (r *MemorySongRepo) update(ctx context.Context, song *domain.Song, apply func(stored *domain.Song) error) error {
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.songs[song.ID]
+	if !ok || stored.TenantID != tenant {
+		return domain.ErrNotFound
+	}
+	if err := apply(&stored); err != nil {
+		return err
+	}
+	r.songs[song.ID] = stored
+	return nil
+}
+
+func (r *MemorySongRepo) Create(ctx context.Context, song *domain.Song) error {
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song.TenantID = tenant
+	if err := r.checkNew(song); err != nil {
+		return err
+	}
+	r.insert(song)
+	return nil
+}
+
+func (r *MemorySongRepo) CreateBatch(ctx context.Context, songs []*domain.Song) error {
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Как и в транзакции: сначала проверяем всё, потом сохраняем.
+	ids := make(map[int]bool, len(songs))
+	keys := make(map[domain.SongKey]bool, len(songs))
+	for _, song := range songs {
+		song.TenantID = tenant
+		if err := r.checkNew(song); err != nil {
+			return err
+		}
+		if song.ID != 0 {
+			if ids[song.ID] {
+				return fmt.Errorf("%w: duplicate id %d", domain.ErrConflict, song.ID)
+			}
+			ids[song.ID] = true
+		}
+		if keys[song.Key()] {
+			return fmt.Errorf("%w: duplicate song %q by %q", domain.ErrConflict, song.Song, song.Group)
+		}
+		keys[song.Key()] = true
+	}
+
+	for _, song := range songs {
+		r.insert(song)
+	}
+	return nil
+}
+
+func (r *MemorySongRepo) ExistingKeys(ctx context.Context, keys []domain.SongKey) (map[domain.SongKey]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs, err := r.filter(ctx, domain.SongFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[domain.SongKey]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+
+	existing := make(map[domain.SongKey]int)
+	for _, song := range songs {
+		key := song.Key()
+		if _, seen := existing[key]; wanted[key] && !seen {
+			existing[key] = song.ID
+		}
+	}
+	return existing, nil
+}
+
+// Stream работает со снимком данных, поэтому fn может обращаться
+// к репозиторию, не рискуя взаимной блокировкой.
+func (r *MemorySongRepo) Stream(ctx context.Context, filter domain.SongFilter, fn func(*domain.Song) error) error {
+	r.mu.RLock()
+	matched, err := r.filter(ctx, filter)
+	r.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for i := range matched {
+		if err := fn(&matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepo) Count(ctx context.Context, filter domain.SongFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched, err := r.filter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(matched)), nil
+}
+
+// checkNew не даёт занять ID, существующий у любого арендатора:
+// ID общие, как и у SERIAL в БД.
+func (r *MemorySongRepo) checkNew(song *domain.Song) error {
+	if _, ok := r.songs[song.ID]; song.ID != 0 && ok {
+		return fmt.Errorf("%w: song with id %d already exists", domain.ErrConflict, song.ID)
+	}
+	return r.checkKey(song)
+}
+
+// checkKey повторяет уникальный индекс (tenant_id, group, song) из миграций.
+func (r *MemorySongRepo) checkKey(song *domain.Song) error {
+	for id, other := range r.songs {
+		if id != song.ID && other.TenantID == song.TenantID && other.Key() == song.Key() {
+			return fmt.Errorf("%w: song %q by %q already exists with id %d", domain.ErrConflict, song.Song, song.Group, id)
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepo) insert(song *domain.Song) {
+	if song.ID == 0 {
+		song.ID = r.nextID
+	}
+	r.songs[song.ID] = *song
+	r.bumpNextID(song.ID)
+}
+
+func (r *MemorySongRepo) bumpNextID(id int) {
+	if id >= r.nextID {
+		r.nextID = id + 1
+	}
+}
+
+func (r *MemorySongRepo) sorted() []domain.Song {
+	songs := make([]domain.Song, 0, len(r.songs))
+	for _, song := range r.songs {
+		songs = append(songs, song)
+	}
+	sort.Slice(songs, func(i, j int) bool {
+		return songs[i].ID < songs[j].ID
+	})
+	return songs
+}
+
+// filter возвращает песни арендатора из ctx, подходящие под фильтр.
+func (r *MemorySongRepo) filter(ctx context.Context, filter domain.SongFilter) ([]domain.Song, error) {
+	tenant, all, err := readTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]domain.Song, 0)
+	for _, song := range r.sorted() {
+		if !all && song.TenantID != tenant {
+			continue
+		}
+		if filter.Group != "" && song.Group != filter.Group {
+			continue
+		}
+		if filter.Song != "" && song.Song != filter.Song {
+			continue
+		}
+		if filter.WithoutText && song.Text != "" {
+			continue
+		}
+		matched = append(matched, song)
+	}
+	return matched, nil
+}
@@ -0,0 +1,14 @@
+package repository_test
+
+import (
+	"test-task/internal/domain"
+	"test-task/internal/repository"
+	"test-task/internal/repository/repotest"
+	"testing"
+)
+
+func TestMemorySongRepo(t *testing.T) {
+	repotest.TestSongRepository(t, func(t *testing.T) domain.SongRepository {
+		return repository.NewMemorySongRepo()
+	})
+}
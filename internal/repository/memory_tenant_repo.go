@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"test-task/internal/domain"
+	"time"
+)
+
+// MemoryTenantRepo хранит арендаторов в памяти процесса. Как и миграция
+// БД, начинает с арендатора по умолчанию.
+type MemoryTenantRepo struct {
+	mu      sync.RWMutex
+	tenants map[string]domain.Tenant
+}
+
+func NewMemoryTenantRepo() domain.TenantRepository {
+	return &MemoryTenantRepo{
+		tenants: map[string]domain.Tenant{
+			domain.DefaultTenant: {ID: domain.DefaultTenant, Name: "Default", CreatedAt: time.Now().UTC()},
+		},
+	}
+}
+
+func (r *MemoryTenantRepo) Create(ctx context.Context, tenant *domain.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenant.ID]; ok {
+		return fmt.Errorf("%w: tenant %s already exists", domain.ErrConflict, tenant.ID)
+	}
+	r.tenants[tenant.ID] = *tenant
+	return nil
+}
+
+func (r *MemoryTenantRepo) GetByID(ctx context.Context, id string) (*domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &tenant, nil
+}
+
+func (r *MemoryTenantRepo) List(ctx context.Context) ([]domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]domain.Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+	return tenants, nil
+}
+
+func (r *MemoryTenantRepo) Update(ctx context.Context, tenant *domain.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.tenants[tenant.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	tenant.CreatedAt = old.CreatedAt
+	r.tenants[tenant.ID] = *tenant
+	return nil
+}
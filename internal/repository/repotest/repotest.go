@@ -0,0 +1,518 @@
+// Package repotest содержит общий набор проверок для реализаций
+// domain.SongRepository. Каждая реализация (gorm, в памяти) должна его
+// проходить, чтобы сервисы вели себя одинаково поверх любого хранилища.
+//
+// Проверки работают с арендатором domain.DefaultTenant и вторым
+// арендатором OtherTenant: если хранилище проверяет арендаторов (внешний
+// ключ в PostgreSQL), оба должны в нём существовать.
+//
+// Пример использования в тесте:
+//
+//	func TestMemorySongRepo(t *testing.T) {
+//		repotest.TestSongRepository(t, func(t *testing.T) domain.SongRepository {
+//			return repository.NewMemorySongRepo()
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"test-task/internal/domain"
+	"testing"
+	"time"
+)
+
+// TestSongRepository прогоняет каждую проверку отдельным подтестом.
+// newRepo должна каждый раз возвращать пустой репозиторий.
+func TestSongRepository(t *testing.T, newRepo func(t *testing.T) domain.SongRepository) {
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(newRepo(t)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+var checks = []struct {
+	name string
+	run  func(r domain.SongRepository) error
+}{
+	{"CreateAndGet", testCreateAndGet},
+	{"GetMissing", testGetMissing},
+	{"GetAllFilters", testGetAllFilters},
+	{"GetAllPagination", testGetAllPagination},
+	{"UpdateTitle", testUpdateTitle},
+	{"UpdateInfo", testUpdateInfo},
+	{"Delete", testDelete},
+	{"CreateConflict", testCreateConflict},
+	{"UniqueKey", testUniqueKey},
+	{"CreateBatch", testCreateBatch},
+	{"ExistingKeys", testExistingKeys},
+	{"Stream", testStream},
+	{"Count", testCount},
+	{"TenantIsolation", testTenantIsolation},
+	{"NoTenant", testNoTenant},
+}
+
+// OtherTenant - второй арендатор для проверки изоляции.
+const OtherTenant = "repotest-other"
+
+// Проверкам нечего отменять, контекст у всех общий.
+var (
+	ctx      = domain.WithTenant(context.Background(), domain.DefaultTenant)
+	otherCtx = domain.WithTenant(context.Background(), OtherTenant)
+)
+
+var releaseDate = time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC)
+
+func sample(group, song string) *domain.Song {
+	return &domain.Song{
+		Group:       group,
+		Song:        song,
+		Text:        "first verse\n\nsecond verse",
+		ReleaseDate: releaseDate,
+		Link:        "https://example.com/" + song,
+	}
+}
+
+// seed сохраняет песни и возвращает их уже с ID.
+func seed(r domain.SongRepository, songs ...*domain.Song) error {
+	for _, s := range songs {
+		if err := r.Create(ctx, s); err != nil {
+			return fmt.Errorf("create %q: %w", s.Song, err)
+		}
+	}
+	return nil
+}
+
+func equalSong(got, want domain.Song) error {
+	// Драйверы возвращают время в разных зонах, сравниваем моменты.
+	if !got.ReleaseDate.Equal(want.ReleaseDate) {
+		return fmt.Errorf("release date = %v, want %v", got.ReleaseDate, want.ReleaseDate)
+	}
+	got.ReleaseDate, want.ReleaseDate = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("song = %+v, want %+v", got, want)
+	}
+	return nil
+}
+
+func ids(songs []domain.Song) []int {
+	out := make([]int, 0, len(songs))
+	for _, s := range songs {
+		out = append(out, s.ID)
+	}
+	return out
+}
+
+func expectIDs(what string, got []domain.Song, want ...int) error {
+	if g := ids(got); !reflect.DeepEqual(g, append([]int{}, want...)) {
+		return fmt.Errorf("%s: ids = %v, want %v", what, g, want)
+	}
+	return nil
+}
+
+func testCreateAndGet(r domain.SongRepository) error {
+	a, b := sample("Muse", "Uprising"), sample("Muse", "Starlight")
+	if err := seed(r, a, b); err != nil {
+		return err
+	}
+	if a.ID == 0 || b.ID <= a.ID {
+		return fmt.Errorf("ids not assigned in increasing order: %d, %d", a.ID, b.ID)
+	}
+
+	got, err := r.GetByID(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+	return equalSong(*got, *b)
+}
+
+func testGetMissing(r domain.SongRepository) error {
+	if _, err := r.GetByID(ctx, 12345); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("GetByID of missing song: err = %v, want domain.ErrNotFound", err)
+	}
+	return nil
+}
+
+func testGetAllFilters(r domain.SongRepository) error {
+	a, b, c := sample("Muse", "Uprising"), sample("Muse", "Starlight"), sample("Queen", "Uprising")
+	if err := seed(r, a, b, c); err != nil {
+		return err
+	}
+
+	cases := []struct {
+		group, song string
+		want        []int
+	}{
+		{"", "", []int{a.ID, b.ID, c.ID}},
+		{"Muse", "", []int{a.ID, b.ID}},
+		{"", "Uprising", []int{a.ID, c.ID}},
+		{"Queen", "Uprising", []int{c.ID}},
+		{"Nobody", "", nil},
+	}
+	for _, tc := range cases {
+		got, err := r.GetAll(ctx, tc.group, tc.song, 0, 10)
+		if err != nil {
+			return err
+		}
+		if err := expectIDs(fmt.Sprintf("group=%q song=%q", tc.group, tc.song), got, tc.want...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testGetAllPagination(r domain.SongRepository) error {
+	var songs []*domain.Song
+	for i := 0; i < 5; i++ {
+		songs = append(songs, sample("Band", fmt.Sprintf("Song %d", i)))
+	}
+	if err := seed(r, songs...); err != nil {
+		return err
+	}
+
+	pages := []struct {
+		offset, limit int
+		want          []int
+	}{
+		{0, 2, []int{songs[0].ID, songs[1].ID}},
+		{2, 2, []int{songs[2].ID, songs[3].ID}},
+		{4, 2, []int{songs[4].ID}},
+		{6, 2, nil},
+	}
+	for _, p := range pages {
+		got, err := r.GetAll(ctx, "", "", p.offset, p.limit)
+		if err != nil {
+			return err
+		}
+		if err := expectIDs(fmt.Sprintf("offset=%d limit=%d", p.offset, p.limit), got, p.want...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testUpdateTitle(r domain.SongRepository) error {
+	s := sample("Muse", "Uprising")
+	if err := seed(r, s); err != nil {
+		return err
+	}
+
+	// Пустое название не меняется, остальные поля не трогаются.
+	upd := &domain.Song{ID: s.ID, Group: "MUSE", UpdatedBy: "editor"}
+	if err := r.UpdateTitle(ctx, upd); err != nil {
+		return err
+	}
+	want := *s
+	want.Group, want.UpdatedBy = "MUSE", "editor"
+	if err := equalSong(*upd, want); err != nil {
+		return fmt.Errorf("returned song: %w", err)
+	}
+
+	got, err := r.GetByID(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	if err := equalSong(*got, want); err != nil {
+		return err
+	}
+
+	missing := &domain.Song{ID: s.ID + 1000, Group: "x"}
+	if err := r.UpdateTitle(ctx, missing); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("UpdateTitle of a missing song: err = %v, want domain.ErrNotFound", err)
+	}
+	return nil
+}
+
+func testUpdateInfo(r domain.SongRepository) error {
+	s := sample("Muse", "Uprising")
+	if err := seed(r, s); err != nil {
+		return err
+	}
+
+	// Устаревшая копия песни не должна вернуть старое название.
+	stale := *s
+	stale.Group = "stale"
+	stale.Text = ""
+	stale.ReleaseDate = releaseDate.AddDate(1, 0, 0)
+	stale.Link = "https://example.com/new"
+	if err := r.UpdateInfo(ctx, &stale); err != nil {
+		return err
+	}
+
+	got, err := r.GetByID(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	want := *s
+	want.Text, want.ReleaseDate, want.Link = stale.Text, stale.ReleaseDate, stale.Link
+	return equalSong(*got, want)
+}
+
+func testDelete(r domain.SongRepository) error {
+	s := sample("Muse", "Uprising")
+	if err := seed(r, s); err != nil {
+		return err
+	}
+
+	if err := r.Delete(ctx, s.ID); err != nil {
+		return err
+	}
+	if _, err := r.GetByID(ctx, s.ID); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("GetByID after Delete: err = %v, want domain.ErrNotFound", err)
+	}
+	if err := r.Delete(ctx, s.ID); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("second Delete: err = %v, want domain.ErrNotFound", err)
+	}
+	return nil
+}
+
+func testCreateConflict(r domain.SongRepository) error {
+	s := sample("Muse", "Uprising")
+	if err := seed(r, s); err != nil {
+		return err
+	}
+
+	dup := sample("Queen", "Innuendo")
+	dup.ID = s.ID
+	if err := r.Create(ctx, dup); !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("Create with existing id: err = %v, want domain.ErrConflict", err)
+	}
+	return nil
+}
+
+func testUniqueKey(r domain.SongRepository) error {
+	s := sample("Muse", "Uprising")
+	if err := seed(r, s); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, sample("Muse", "Uprising")); !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("Create of the same song: err = %v, want domain.ErrConflict", err)
+	}
+	batch := []*domain.Song{sample("Muse", "Starlight"), sample("Muse", "Starlight")}
+	if err := r.CreateBatch(ctx, batch); !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("CreateBatch with a repeated song: err = %v, want domain.ErrConflict", err)
+	}
+	if count, err := r.Count(ctx, domain.SongFilter{}); err != nil || count != 1 {
+		return fmt.Errorf("Count after failed CreateBatch = %d, %v, want 1", count, err)
+	}
+
+	other := sample("Muse", "Hysteria")
+	if err := seed(r, other); err != nil {
+		return err
+	}
+	if err := r.UpdateTitle(ctx, &domain.Song{ID: other.ID, Song: "Uprising"}); !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("UpdateTitle to an existing song: err = %v, want domain.ErrConflict", err)
+	}
+
+	// У другого арендатора своя библиотека.
+	if err := r.Create(otherCtx, sample("Muse", "Uprising")); err != nil {
+		return fmt.Errorf("Create of the same song for another tenant: %w", err)
+	}
+	return nil
+}
+
+func testCreateBatch(r domain.SongRepository) error {
+	batch := []*domain.Song{sample("A", "1"), sample("A", "2"), sample("B", "1")}
+	if err := r.CreateBatch(ctx, batch); err != nil {
+		return err
+	}
+	for _, s := range batch {
+		if s.ID == 0 {
+			return fmt.Errorf("CreateBatch did not assign id to %q", s.Song)
+		}
+	}
+
+	// Пачка с конфликтом не должна сохраниться частично.
+	bad := []*domain.Song{sample("C", "1"), sample("C", "2")}
+	bad[1].ID = batch[0].ID
+	if err := r.CreateBatch(ctx, bad); !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("CreateBatch with existing id: err = %v, want domain.ErrConflict", err)
+	}
+	got, err := r.GetAll(ctx, "C", "", 0, 10)
+	if err != nil {
+		return err
+	}
+	return expectIDs("after failed batch", got)
+}
+
+func testExistingKeys(r domain.SongRepository) error {
+	a, b := sample("Muse", "Uprising"), sample("Queen", "Innuendo")
+	if err := seed(r, a, b); err != nil {
+		return err
+	}
+
+	got, err := r.ExistingKeys(ctx, []domain.SongKey{
+		a.Key(),
+		{Group: "Muse", Song: "Innuendo"},
+		b.Key(),
+	})
+	if err != nil {
+		return err
+	}
+
+	want := map[domain.SongKey]int{a.Key(): a.ID, b.Key(): b.ID}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("ExistingKeys = %v, want %v", got, want)
+	}
+	return nil
+}
+
+func testStream(r domain.SongRepository) error {
+	a, b, c := sample("Muse", "Uprising"), sample("Muse", "Starlight"), sample("Queen", "Innuendo")
+	b.Text = ""
+	if err := seed(r, a, b, c); err != nil {
+		return err
+	}
+
+	collect := func(filter domain.SongFilter) ([]domain.Song, error) {
+		var out []domain.Song
+		err := r.Stream(ctx, filter, func(s *domain.Song) error {
+			out = append(out, *s)
+			return nil
+		})
+		return out, err
+	}
+
+	all, err := collect(domain.SongFilter{})
+	if err != nil {
+		return err
+	}
+	if err := expectIDs("all", all, a.ID, b.ID, c.ID); err != nil {
+		return err
+	}
+
+	muse, err := collect(domain.SongFilter{Group: "Muse"})
+	if err != nil {
+		return err
+	}
+	if err := expectIDs("group=Muse", muse, a.ID, b.ID); err != nil {
+		return err
+	}
+
+	noText, err := collect(domain.SongFilter{WithoutText: true})
+	if err != nil {
+		return err
+	}
+	if err := expectIDs("without text", noText, b.ID); err != nil {
+		return err
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = r.Stream(ctx, domain.SongFilter{}, func(*domain.Song) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		return fmt.Errorf("error from callback: err = %v after %d calls, want stop after 1", err, calls)
+	}
+	return nil
+}
+
+func testCount(r domain.SongRepository) error {
+	a, b, c := sample("Muse", "Uprising"), sample("Muse", "Starlight"), sample("Queen", "Innuendo")
+	b.Text = ""
+	if err := seed(r, a, b, c); err != nil {
+		return err
+	}
+
+	for _, tc := range []struct {
+		filter domain.SongFilter
+		want   int64
+	}{
+		{domain.SongFilter{}, 3},
+		{domain.SongFilter{Group: "Muse"}, 2},
+		{domain.SongFilter{Group: "Muse", Song: "Uprising"}, 1},
+		{domain.SongFilter{WithoutText: true}, 1},
+		{domain.SongFilter{Group: "Nobody"}, 0},
+	} {
+		got, err := r.Count(ctx, tc.filter)
+		if err != nil {
+			return err
+		}
+		if got != tc.want {
+			return fmt.Errorf("Count(%+v) = %d, want %d", tc.filter, got, tc.want)
+		}
+	}
+	return nil
+}
+
+func testTenantIsolation(r domain.SongRepository) error {
+	mine := sample("Muse", "Uprising")
+	if err := seed(r, mine); err != nil {
+		return err
+	}
+	theirs := sample("Muse", "Uprising")
+	if err := r.Create(otherCtx, theirs); err != nil {
+		return fmt.Errorf("create in %s: %w", OtherTenant, err)
+	}
+	if theirs.TenantID != OtherTenant {
+		return fmt.Errorf("tenant = %q, want %q", theirs.TenantID, OtherTenant)
+	}
+
+	if _, err := r.GetByID(ctx, theirs.ID); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("GetByID of another tenant's song: err = %v, want domain.ErrNotFound", err)
+	}
+	got, err := r.GetAll(ctx, "", "", 0, 10)
+	if err != nil {
+		return err
+	}
+	if err := expectIDs("GetAll", got, mine.ID); err != nil {
+		return err
+	}
+	if count, err := r.Count(ctx, domain.SongFilter{}); err != nil || count != 1 {
+		return fmt.Errorf("Count = %d, %v, want 1", count, err)
+	}
+	existing, err := r.ExistingKeys(ctx, []domain.SongKey{theirs.Key()})
+	if err != nil {
+		return err
+	}
+	if existing[theirs.Key()] != mine.ID {
+		return fmt.Errorf("ExistingKeys = %v, want only id %d", existing, mine.ID)
+	}
+
+	stolen := *theirs
+	stolen.Song, stolen.Text = "stolen", "stolen"
+	if err := r.UpdateTitle(ctx, &stolen); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("UpdateTitle of another tenant's song: err = %v, want domain.ErrNotFound", err)
+	}
+	if err := r.UpdateInfo(ctx, &stolen); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("UpdateInfo of another tenant's song: err = %v, want domain.ErrNotFound", err)
+	}
+	if err := r.Delete(ctx, theirs.ID); !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("Delete of another tenant's song: err = %v, want domain.ErrNotFound", err)
+	}
+	kept, err := r.GetByID(otherCtx, theirs.ID)
+	if err != nil {
+		return err
+	}
+	if err := equalSong(*kept, *theirs); err != nil {
+		return fmt.Errorf("another tenant's song changed: %w", err)
+	}
+
+	all, err := r.GetAll(domain.WithAllTenants(context.Background()), "", "", 0, 10)
+	if err != nil {
+		return err
+	}
+	return expectIDs("GetAll for all tenants", all, mine.ID, theirs.ID)
+}
+
+func testNoTenant(r domain.SongRepository) error {
+	if _, err := r.GetAll(context.Background(), "", "", 0, 10); err == nil {
+		return fmt.Errorf("GetAll without a tenant succeeded")
+	}
+	if err := r.Create(context.Background(), sample("Muse", "Uprising")); err == nil {
+		return fmt.Errorf("Create without a tenant succeeded")
+	}
+	if err := r.Create(domain.WithAllTenants(context.Background()), sample("Muse", "Uprising")); err == nil {
+		return fmt.Errorf("Create for all tenants succeeded")
+	}
+	return nil
+}
@@ -1,27 +1,93 @@
 package repository
 
 import (
+	"context"
 	"test-task/internal/domain"
 	"test-task/pkg/logging"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// songModel - строка таблицы songs.
+type songModel struct {
+	ID          int    `gorm:"primaryKey;autoIncrement"`
+	TenantID    string `gorm:"type:varchar(64);not null"`
+	Group       string `gorm:"type:varchar(100);not null"`
+	Song        string `gorm:"type:varchar(100);not null"`
+	Text        string `gorm:"type:text"`
+	ReleaseDate time.Time
+	Link        string `gorm:"type:varchar(255)"`
+	CreatedBy   string `gorm:"type:varchar(255)"`
+	UpdatedBy   string `gorm:"type:varchar(255)"`
+}
+
+func (songModel) TableName() string {
+	return "songs"
+}
+
+func toSongModel(s *domain.Song) *songModel {
+	return &songModel{
+		ID:          s.ID,
+		TenantID:    s.TenantID,
+		Group:       s.Group,
+		Song:        s.Song,
+		Text:        s.Text,
+		ReleaseDate: s.ReleaseDate,
+		Link:        s.Link,
+		CreatedBy:   s.CreatedBy,
+		UpdatedBy:   s.UpdatedBy,
+	}
+}
+
+func (m *songModel) toDomain() domain.Song {
+	return domain.Song{
+		ID:          m.ID,
+		TenantID:    m.TenantID,
+		Group:       m.Group,
+		Song:        m.Song,
+		Text:        m.Text,
+		ReleaseDate: m.ReleaseDate,
+		Link:        m.Link,
+		CreatedBy:   m.CreatedBy,
+		UpdatedBy:   m.UpdatedBy,
+	}
+}
+
+func toSongs(models []songModel) []domain.Song {
+	songs := make([]domain.Song, len(models))
+	for i := range models {
+		songs[i] = models[i].toDomain()
+	}
+	return songs
+}
+
 type SongRepo struct {
-	db  *gorm.DB
-	log logging.Logger
+	db *gorm.DB
 }
 
 func NewSongRepo(db *gorm.DB) domain.SongRepository {
-	return &SongRepo{
-		db:  db,
-		log: logging.GetLogger(),
-	}
+	return &SongRepo{db: db}
+}
+
+// query - запрос к песням арендатора из ctx. Все методы начинают с него,
+// поэтому песни другого арендатора не видны ни при чтении, ни при записи.
+func (r *SongRepo) query(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Scopes(tenantScope(ctx))
+}
+
+// primary направляет чтение в основную БД, даже если настроена реплика.
+// На реплику уходят только GetAll и GetByID; проверка дубликатов перед
+// записью, ответ на изменение, выгрузка и подсчёт должны видеть уже
+// сохранённые данные.
+func (r *SongRepo) primary(ctx context.Context) *gorm.DB {
+	return r.query(ctx).Clauses(dbresolver.Write)
 }
 
-func (r *SongRepo) GetAll(group, song string, offset, limit int) ([]domain.Song, error) {
-	var songs []domain.Song
-	query := r.db
+func (r *SongRepo) GetAll(ctx context.Context, group, song string, offset, limit int) ([]domain.Song, error) {
+	var models []songModel
+	query := r.query(ctx)
 
 	if group != "" {
 		query = query.Where(`"group"= ?`, group)
@@ -31,43 +97,222 @@ func (r *SongRepo) GetAll(group, song string, offset, limit int) ([]domain.Song,
 		query = query.Where(`"song"= ?`, song)
 	}
 
-	if err := query.Limit(limit).Offset(offset).Find(&songs).Error; err != nil {
-		r.log.Error(err.Error())
-		return nil, err
+	if err := query.Order("id").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, translateError(err)
 	}
 
-	return songs, nil
+	return toSongs(models), nil
 }
 
-func (r *SongRepo) GetByID(id int) (*domain.Song, error) {
-	var song domain.Song
-	if err := r.db.First(&song, id).Error; err != nil {
-		r.log.Error(err.Error())
-		return nil, err
+func (r *SongRepo) GetByID(ctx context.Context, id int) (*domain.Song, error) {
+	var m songModel
+	if err := r.query(ctx).First(&m, id).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, translateError(err)
 	}
+	song := m.toDomain()
 	return &song, nil
 }
 
-func (r *SongRepo) Delete(id int) error {
-	if err := r.db.Delete(&domain.Song{}, id).Error; err != nil {
-		r.log.Error(err.Error())
+func (r *SongRepo) Delete(ctx context.Context, id int) error {
+	if _, err := writeTenant(ctx); err != nil {
 		return err
 	}
+	result := r.query(ctx).Delete(&songModel{}, id)
+	if err := result.Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
 	return nil
 }
 
-func (r *SongRepo) Update(song *domain.Song) error {
-	if err := r.db.Save(song).Error; err != nil {
-		r.log.Error(err.Error())
+// UpdateTitle меняет только переданные столбцы, а не всю строку: иначе
+// одновременное обогащение потеряло бы текст или название. Результат
+// читается с основной БД, на реплику изменение могло ещё не дойти.
+func (r *SongRepo) UpdateTitle(ctx context.Context, song *domain.Song) error {
+	if _, err := writeTenant(ctx); err != nil {
 		return err
 	}
+
+	columns := map[string]any{"updated_by": song.UpdatedBy}
+	if song.Group != "" {
+		columns["group"] = song.Group
+	}
+	if song.Song != "" {
+		columns["song"] = song.Song
+	}
+	if err := r.update(ctx, song.ID, columns); err != nil {
+		return err
+	}
+
+	var m songModel
+	if err := r.primary(ctx).First(&m, song.ID).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	*song = m.toDomain()
 	return nil
 }
 
-func (r *SongRepo) Create(song *domain.Song) error {
-	if err := r.db.Create(song).Error; err != nil {
-		r.log.Error(err.Error())
+func (r *SongRepo) UpdateInfo(ctx context.Context, song *domain.Song) error {
+	if _, err := writeTenant(ctx); err != nil {
 		return err
 	}
+	return r.update(ctx, song.ID, map[string]any{
+		"text":         song.Text,
+		"release_date": song.ReleaseDate,
+		"link":         song.Link,
+	})
+}
+
+// update меняет столбцы существующей песни своего арендатора. Save здесь
+// не подходит: не найдя строку, он вставил бы её, а при совпавшем ID
+// перезаписал бы песню другого арендатора.
+func (r *SongRepo) update(ctx context.Context, id int, columns map[string]any) error {
+	result := r.query(ctx).Model(&songModel{}).Where("id = ?", id).Updates(columns)
+	if err := result.Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
 	return nil
 }
+
+func (r *SongRepo) Create(ctx context.Context, song *domain.Song) error {
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+	song.TenantID = tenant
+
+	m := toSongModel(song)
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	song.ID = m.ID
+	return nil
+}
+
+const (
+	createBatchSize = 100
+	keysChunkSize   = 500
+)
+
+// CreateBatch сохраняет песни одной транзакцией: либо все, либо ни одной.
+func (r *SongRepo) CreateBatch(ctx context.Context, songs []*domain.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+	tenant, err := writeTenant(ctx)
+	if err != nil {
+		return err
+	}
+	models := make([]*songModel, len(songs))
+	for i, song := range songs {
+		song.TenantID = tenant
+		models[i] = toSongModel(song)
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(models, createBatchSize).Error
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	for i, m := range models {
+		songs[i].ID = m.ID
+	}
+	return nil
+}
+
+// ExistingKeys возвращает ID уже сохранённых песен для переданных ключей.
+func (r *SongRepo) ExistingKeys(ctx context.Context, keys []domain.SongKey) (map[domain.SongKey]int, error) {
+	existing := make(map[domain.SongKey]int)
+
+	for start := 0; start < len(keys); start += keysChunkSize {
+		end := min(start+keysChunkSize, len(keys))
+
+		pairs := make([][]interface{}, 0, end-start)
+		for _, k := range keys[start:end] {
+			pairs = append(pairs, []interface{}{k.Group, k.Song})
+		}
+
+		var models []songModel
+		err := r.primary(ctx).Select("id", "group", "song").
+			Where(`("group", "song") IN ?`, pairs).
+			Find(&models).Error
+		if err != nil {
+			logging.FromContext(ctx).Error(err.Error())
+			return nil, translateError(err)
+		}
+
+		for _, m := range models {
+			existing[domain.SongKey{Group: m.Group, Song: m.Song}] = m.ID
+		}
+	}
+
+	return existing, nil
+}
+
+// Stream построчно читает песни, подходящие под фильтр, и передаёт их в fn,
+// не загружая выборку в память целиком. Ошибка из fn прерывает чтение.
+func (r *SongRepo) Stream(ctx context.Context, filter domain.SongFilter, fn func(*domain.Song) error) error {
+	rows, err := r.filtered(ctx, filter).Order("id").Rows()
+	if err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m songModel
+		if err := r.db.ScanRows(rows, &m); err != nil {
+			logging.FromContext(ctx).Error(err.Error())
+			return translateError(err)
+		}
+		song := m.toDomain()
+		if err := fn(&song); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *SongRepo) Count(ctx context.Context, filter domain.SongFilter) (int64, error) {
+	var count int64
+	if err := r.filtered(ctx, filter).Count(&count).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return 0, translateError(err)
+	}
+	return count, nil
+}
+
+func (r *SongRepo) filtered(ctx context.Context, filter domain.SongFilter) *gorm.DB {
+	query := r.primary(ctx).Model(&songModel{})
+
+	if filter.Group != "" {
+		query = query.Where(`"group"= ?`, filter.Group)
+	}
+
+	if filter.Song != "" {
+		query = query.Where(`"song"= ?`, filter.Song)
+	}
+
+	if filter.WithoutText {
+		query = query.Where(`"text" IS NULL OR "text" = ''`)
+	}
+	return query
+}
@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"test-task/internal/domain"
+	"test-task/internal/repository"
+	"test-task/internal/repository/repotest"
+	"test-task/pkg/config"
+	"test-task/pkg/db"
+	"testing"
+	"time"
+)
+
+func TestSongRepoSQLite(t *testing.T) {
+	repotest.TestSongRepository(t, newSQLiteSongRepo)
+}
+
+// newSQLiteSongRepo - репозиторий над пустой базой SQLite в памяти
+// со всеми миграциями и вторым арендатором для проверок изоляции.
+func newSQLiteSongRepo(t *testing.T) domain.SongRepository {
+	t.Helper()
+	ctx := context.Background()
+
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.Path = ":memory:"
+	gdb, err := db.InitDB(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := db.NewMigrator(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	other := &domain.Tenant{ID: repotest.OtherTenant, Name: "Other", CreatedAt: time.Now().UTC()}
+	if err := repository.NewTenantRepo(gdb).Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	return repository.NewSongRepo(gdb)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"test-task/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// errNoTenant - запрос к песням без арендатора в контексте. Это ошибка
+// в коде, а не у клиента: лучше не выполнить запрос, чем показать
+// или изменить чужие песни.
+var errNoTenant = errors.New("no tenant in context")
+
+// readTenant - арендатор, чьи песни можно читать. all означает, что
+// контекст системной задачи открыт для всех арендаторов.
+func readTenant(ctx context.Context) (id string, all bool, err error) {
+	if domain.AllTenants(ctx) {
+		return "", true, nil
+	}
+	id, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return "", false, errNoTenant
+	}
+	return id, false, nil
+}
+
+// writeTenant - арендатор, чьи песни можно менять. Системный контекст
+// для всех арендаторов для записи не подходит.
+func writeTenant(ctx context.Context) (string, error) {
+	id, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return "", errNoTenant
+	}
+	return id, nil
+}
+
+// tenantScope ограничивает запрос песнями арендатора из ctx. Без
+// арендатора запрос не выполняется и возвращает errNoTenant.
+func tenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, all, err := readTenant(ctx)
+		switch {
+		case err != nil:
+			db.AddError(err)
+			return db
+		case all:
+			return db
+		}
+		return db.Where("tenant_id = ?", id)
+	}
+}
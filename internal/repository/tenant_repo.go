@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// tenantModel - строка таблицы tenants. Без своего провайдера текстов
+// lyrics_url равен NULL.
+type tenantModel struct {
+	ID        string `gorm:"primaryKey"`
+	Name      string
+	MaxSongs  int
+	LyricsURL *string
+	CreatedAt time.Time
+}
+
+func (tenantModel) TableName() string {
+	return "tenants"
+}
+
+func toTenantModel(t *domain.Tenant) *tenantModel {
+	var lyricsURL *string
+	if t.LyricsURL != "" {
+		lyricsURL = &t.LyricsURL
+	}
+	return &tenantModel{
+		ID:        t.ID,
+		Name:      t.Name,
+		MaxSongs:  t.MaxSongs,
+		LyricsURL: lyricsURL,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func (m *tenantModel) toDomain() domain.Tenant {
+	var lyricsURL string
+	if m.LyricsURL != nil {
+		lyricsURL = *m.LyricsURL
+	}
+	return domain.Tenant{
+		ID:        m.ID,
+		Name:      m.Name,
+		MaxSongs:  m.MaxSongs,
+		LyricsURL: lyricsURL,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+type TenantRepo struct {
+	db *gorm.DB
+}
+
+func NewTenantRepo(db *gorm.DB) domain.TenantRepository {
+	return &TenantRepo{db: db}
+}
+
+// Арендаторы читаются из основной БД: новая квота или провайдер
+// должны действовать сразу, а не когда изменение дойдёт до реплики.
+func (r *TenantRepo) query(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Clauses(dbresolver.Write)
+}
+
+func (r *TenantRepo) Create(ctx context.Context, tenant *domain.Tenant) error {
+	if err := r.query(ctx).Create(toTenantModel(tenant)).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	return nil
+}
+
+func (r *TenantRepo) GetByID(ctx context.Context, id string) (*domain.Tenant, error) {
+	var m tenantModel
+	if err := r.query(ctx).Where("id = ?", id).First(&m).Error; err != nil {
+		return nil, translateError(err)
+	}
+	tenant := m.toDomain()
+	return &tenant, nil
+}
+
+func (r *TenantRepo) List(ctx context.Context) ([]domain.Tenant, error) {
+	var models []tenantModel
+	if err := r.query(ctx).Order("id").Find(&models).Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return nil, translateError(err)
+	}
+
+	tenants := make([]domain.Tenant, len(models))
+	for i := range models {
+		tenants[i] = models[i].toDomain()
+	}
+	return tenants, nil
+}
+
+func (r *TenantRepo) Update(ctx context.Context, tenant *domain.Tenant) error {
+	m := toTenantModel(tenant)
+	result := r.query(ctx).Model(m).Select("name", "max_songs", "lyrics_url").Updates(m)
+	if err := result.Error; err != nil {
+		logging.FromContext(ctx).Error(err.Error())
+		return translateError(err)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+)
+
+// Ключ выглядит как sl_<prefix>_<secret>: prefix ищется в БД,
+// secret проверяется по хешу.
+const (
+	apiKeyTag         = "sl"
+	apiKeyPrefixBytes = 8
+	apiKeySecretBytes = 32
+	maxAPIKeyNameLen  = 100
+)
+
+type APIKeyService struct {
+	keyRepo domain.APIKeyRepository
+	now     func() time.Time
+}
+
+func NewAPIKeyService(keyRepo domain.APIKeyRepository) domain.APIKeyService {
+	return &APIKeyService{keyRepo: keyRepo, now: time.Now}
+}
+
+func (s *APIKeyService) CreateKey(ctx context.Context, name, tenant string, scopes []domain.Scope, expiresAt *time.Time) (string, *domain.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLen {
+		return "", nil, fmt.Errorf("%w: key name must be 1 to %d characters long", domain.ErrInvalidInput, maxAPIKeyNameLen)
+	}
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", domain.ErrInvalidInput)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(domain.Scopes, scope) {
+			return "", nil, fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidInput, scope)
+		}
+	}
+	now := s.now().UTC()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, fmt.Errorf("%w: expiry must be in the future", domain.ErrInvalidInput)
+	}
+
+	prefix, err := randomString(apiKeyPrefixBytes, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomString(apiKeySecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	token := apiKeyTag + "_" + prefix + "_" + secret
+
+	key := &domain.APIKey{
+		Name:      name,
+		TenantID:  tenant,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(token),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		logging.FromContext(ctx).Error("failed to save API key: ", err)
+		return "", nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+	return token, key, nil
+}
+
+// Authenticate находит действующий ключ по его открытому значению.
+// Причина отказа уходит в лог, клиент получает только ErrUnauthorized.
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*domain.APIKey, error) {
+	prefix, ok := parseAPIKey(token)
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed API key", domain.ErrUnauthorized)
+	}
+
+	key, err := s.keyRepo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%w: invalid API key", domain.ErrUnauthorized)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to look up API key: ", err)
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(token))) != 1 {
+		return nil, fmt.Errorf("%w: invalid API key", domain.ErrUnauthorized)
+	}
+	if !key.Active(s.now()) {
+		return nil, fmt.Errorf("%w: API key %s is revoked or expired", domain.ErrUnauthorized, key.Prefix)
+	}
+	return key, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	keys, err := s.keyRepo.List(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list API keys: ", err)
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int) error {
+	if err := s.keyRepo.Revoke(ctx, id, s.now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("active API key with id %d %w", id, domain.ErrNotFound)
+		}
+		logging.FromContext(ctx).Error("failed to revoke API key: ", err)
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+func parseAPIKey(token string) (prefix string, ok bool) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || len(parts[1]) != hex.EncodedLen(apiKeyPrefixBytes) {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashAPIKey - SHA-256 ключа. Ключ - 32 случайных байта, поэтому
+// медленный хеш вроде bcrypt здесь не нужен.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return encode(b), nil
+}
@@ -1,48 +1,63 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"test-task/internal/domain"
-	"test-task/internal/dto"
 	"test-task/pkg/logging"
-
-	"gorm.io/gorm"
 )
 
 type SongService struct {
-	songRepo domain.SongRepository
-	log      logging.Logger
+	songRepo   domain.SongRepository
+	tenantRepo domain.TenantRepository
 }
 
-func NewSongService(songRepo domain.SongRepository) domain.SongService {
-	return &SongService{
-		songRepo: songRepo,
-		log:      logging.GetLogger(),
-	}
+func NewSongService(songRepo domain.SongRepository, tenantRepo domain.TenantRepository) domain.SongService {
+	return &SongService{songRepo: songRepo, tenantRepo: tenantRepo}
 }
 
-func (s *SongService) GetSongs(group, song string, page, limit int) ([]domain.Song, error) {
+func (s *SongService) GetSongs(ctx context.Context, group, song string, page, limit int) ([]domain.Song, error) {
 	offset := (page - 1) * limit
-	songs, err := s.songRepo.GetAll(group, song, offset, limit)
+	songs, err := s.songRepo.GetAll(ctx, group, song, offset, limit)
 	if err != nil {
-		s.log.Error("failed to fetch songs: ", err)
-		return nil, fmt.Errorf("failed to fetch songs")
+		logging.FromContext(ctx).Error("failed to fetch songs: ", err)
+		return nil, fmt.Errorf("failed to fetch songs: %w", err)
 	}
 	return songs, nil
 }
 
-func (s *SongService) GetTextBySongID(id, page, limit int) ([]string, error) {
+func (s *SongService) ExportSongs(ctx context.Context, filter domain.SongFilter, fn func(*domain.Song) error) error {
+	if err := s.songRepo.Stream(ctx, filter, fn); err != nil {
+		logging.FromContext(ctx).Error("failed to export songs: ", err)
+		return fmt.Errorf("failed to export songs: %w", err)
+	}
+	return nil
+}
 
-	song, err := s.songRepo.GetByID(id)
+func (s *SongService) CountSongs(ctx context.Context, filter domain.SongFilter) (int64, error) {
+	count, err := s.songRepo.Count(ctx, filter)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.log.Error("song not found: ", err)
-			return nil, fmt.Errorf("song with id %d not found", id)
-		}
-		s.log.Error("failed to retrieve data: ", err)
-		return nil, fmt.Errorf("failed to retrieve data")
+		logging.FromContext(ctx).Error("failed to count songs: ", err)
+		return 0, fmt.Errorf("failed to count songs: %w", err)
+	}
+	return count, nil
+}
+
+func (s *SongService) GetSong(ctx context.Context, id int) (*domain.Song, error) {
+	song, err := s.songRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.getByIDError(ctx, id, err)
+	}
+	return song, nil
+}
+
+func (s *SongService) GetTextBySongID(ctx context.Context, id, page, limit int) ([]string, error) {
+
+	song, err := s.songRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, s.getByIDError(ctx, id, err)
 	}
 
 	if song.Text == "" {
@@ -65,56 +80,231 @@ func (s *SongService) GetTextBySongID(id, page, limit int) ([]string, error) {
 	return texts[start:end], nil
 }
 
-func (s *SongService) DeleteSong(id int) error {
-	if err := s.songRepo.Delete(id); err != nil {
-		s.log.Error("deletion failed: ", err)
-		return fmt.Errorf("deletion failed")
+func (s *SongService) DeleteSong(ctx context.Context, id int) error {
+	if err := s.songRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logging.FromContext(ctx).Error("song not found: ", err)
+			return fmt.Errorf("song with id %d %w", id, domain.ErrNotFound)
+		}
+		logging.FromContext(ctx).Error("deletion failed: ", err)
+		return fmt.Errorf("deletion failed: %w", err)
 	}
 	return nil
 }
 
-func (s *SongService) UpdateSong(id int, updateSong *domain.Song) (*domain.Song, error) {
-	song, err := s.songRepo.GetByID(id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.log.Error("song not found: ", err)
-			return nil, fmt.Errorf("song with id %d not found", id)
+// UpdateSong меняет группу и название. Песня не читается заранее:
+// репозиторий меняет только эти поля и сам возвращает сохранённую песню,
+// поэтому изменение не затрёт текст, который в это же время сохраняет
+// обогащение.
+func (s *SongService) UpdateSong(ctx context.Context, id int, updateSong *domain.Song) (*domain.Song, error) {
+	song := &domain.Song{
+		ID:        id,
+		Group:     updateSong.Group,
+		Song:      updateSong.Song,
+		UpdatedBy: domain.ActorFromContext(ctx),
+	}
+	if err := s.songRepo.UpdateTitle(ctx, song); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, s.getByIDError(ctx, id, err)
 		}
-		s.log.Error("failed to retrieve data: ", err)
-		return nil, fmt.Errorf("failed to retrieve data")
+		logging.FromContext(ctx).Error("failed to update data: ", err)
+		return nil, fmt.Errorf("failed to update data: %w", err)
 	}
+	return song, nil
+}
 
-	if updateSong.Group != "" {
-		song.Group = updateSong.Group
+func (s *SongService) CreateSong(ctx context.Context, song *domain.Song) error {
+	if err := s.checkQuota(ctx, 1); err != nil {
+		return err
 	}
-	if updateSong.Song != "" {
-		song.Song = updateSong.Song
+	song.CreatedBy = domain.ActorFromContext(ctx)
+	song.UpdatedBy = song.CreatedBy
+	if err := s.songRepo.Create(ctx, song); err != nil {
+		logging.FromContext(ctx).Error("failed to save song: ", err)
+		return fmt.Errorf("failed to save song: %w", err)
 	}
+	return nil
+}
 
-	if err := s.songRepo.Update(song); err != nil {
-		s.log.Error("failed to update data: ", err)
-		return nil, fmt.Errorf("failed to update data")
+func (s *SongService) UpdateSongInfo(ctx context.Context, song *domain.Song, info domain.SongInfo) error {
+	song.Text = info.Text
+	song.ReleaseDate = info.ReleaseDate
+	song.Link = info.Link
+	if err := s.songRepo.UpdateInfo(ctx, song); err != nil {
+		logging.FromContext(ctx).Error("failed to save song: ", err)
+		return err
 	}
-	return song, nil
+	return nil
 }
 
-func (s *SongService) CreateSong(song *domain.Song) error {
-	if err := s.songRepo.Create(song); err != nil {
-		s.log.Error("failed to save song: ", err)
-		return fmt.Errorf("failed to save song")
+// getByIDError превращает ошибку чтения песни в ошибку для клиента,
+// сохраняя её вид (domain.ErrNotFound и т.п.) для errors.Is.
+func (s *SongService) getByIDError(ctx context.Context, id int, err error) error {
+	log := logging.FromContext(ctx)
+	if errors.Is(err, domain.ErrNotFound) {
+		log.Error("song not found: ", err)
+		return fmt.Errorf("song with id %d %w", id, domain.ErrNotFound)
 	}
-	return nil
+	log.Error("failed to retrieve data: ", err)
+	return fmt.Errorf("failed to retrieve data: %w", err)
 }
 
-func (s *SongService) UpdateSongInfo(song *domain.Song, data interface{}) error {
-	ext_api_data := data.(dto.ExternalAPIResponse)
+// checkQuota проверяет, что арендатор может добавить ещё n песен.
+// Параллельные запросы могут немного превысить квоту: она ограничивает
+// размер библиотеки, а не служит строгим счётчиком.
+func (s *SongService) checkQuota(ctx context.Context, n int) error {
+	id, ok := domain.TenantFromContext(ctx)
+	if !ok || n == 0 {
+		return nil
+	}
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to look up tenant: ", err)
+		return fmt.Errorf("failed to look up tenant %s: %w", id, err)
+	}
+	if tenant.MaxSongs == 0 {
+		return nil
+	}
 
-	song.Text = ext_api_data.Text
-	song.ReleaseDate = ext_api_data.ReleaseDate
-	song.Link = ext_api_data.Link
-	if err := s.songRepo.Update(song); err != nil {
-		s.log.Error("failed to save song: ", err)
-		return err
+	count, err := s.songRepo.Count(ctx, domain.SongFilter{})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to count songs: ", err)
+		return fmt.Errorf("failed to count songs: %w", err)
+	}
+	if count+int64(n) > int64(tenant.MaxSongs) {
+		return fmt.Errorf("%w: tenant %s may keep at most %d songs, has %d, adding %d", domain.ErrQuotaExceeded, id, tenant.MaxSongs, count, n)
 	}
 	return nil
 }
+
+const importChunkSize = 100
+
+// ImportSongs сохраняет песни пачками, каждая пачка - отдельной транзакцией.
+// Песни, которые уже есть в БД или повторяются в самом запросе, помечаются
+// как дубликаты. В режиме Atomic любой дубликат отменяет импорт целиком,
+// а все песни сохраняются одной транзакцией. В режиме DryRun ничего
+// не сохраняется, а статус created означает, что песня была бы создана.
+func (s *SongService) ImportSongs(ctx context.Context, songs []*domain.Song, opts domain.ImportOptions) ([]domain.ImportResult, error) {
+	results := make([]domain.ImportResult, len(songs))
+
+	actor := domain.ActorFromContext(ctx)
+	keys := make([]domain.SongKey, len(songs))
+	for i, song := range songs {
+		song.CreatedBy, song.UpdatedBy = actor, actor
+		keys[i] = song.Key()
+	}
+
+	existing, err := s.songRepo.ExistingKeys(ctx, keys)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check duplicates: ", err)
+		return nil, fmt.Errorf("failed to check duplicates: %w", err)
+	}
+
+	seen := make(map[domain.SongKey]struct{}, len(songs))
+	fresh := make([]int, 0, len(songs))
+	for i, song := range songs {
+		if id, ok := existing[keys[i]]; ok {
+			results[i] = domain.ImportResult{
+				Status: domain.ImportDuplicate,
+				Song:   song,
+				Reason: fmt.Sprintf("song already exists with id %d", id),
+			}
+			continue
+		}
+		if _, ok := seen[keys[i]]; ok {
+			results[i] = domain.ImportResult{
+				Status: domain.ImportDuplicate,
+				Song:   song,
+				Reason: "song appears more than once in this import",
+			}
+			continue
+		}
+		seen[keys[i]] = struct{}{}
+		fresh = append(fresh, i)
+	}
+
+	if opts.Atomic && len(fresh) != len(songs) {
+		markSkipped(results, songs, fresh, "import aborted: batch contains duplicates")
+		return results, nil
+	}
+
+	// Импорт, не влезающий в квоту, отклоняется целиком, и в пробном
+	// режиме тоже: пусть клиент узнает об этом до настоящей загрузки.
+	if err := s.checkQuota(ctx, len(fresh)); err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		markCreated(results, songs, fresh)
+		return results, nil
+	}
+
+	if opts.Atomic {
+		if err := s.songRepo.CreateBatch(ctx, songs); err != nil {
+			logging.FromContext(ctx).Error("failed to save songs: ", err)
+			return nil, fmt.Errorf("failed to save songs: %w", err)
+		}
+		markCreated(results, songs, fresh)
+		return results, nil
+	}
+
+	created := 0
+	for start := 0; start < len(fresh); start += importChunkSize {
+		chunk := fresh[start:min(start+importChunkSize, len(fresh))]
+
+		batch := make([]*domain.Song, len(chunk))
+		for j, i := range chunk {
+			batch[j] = songs[i]
+		}
+
+		err := s.songRepo.CreateBatch(ctx, batch)
+		switch {
+		case err == nil:
+			markCreated(results, songs, chunk)
+			created += len(chunk)
+		case errors.Is(err, domain.ErrConflict):
+			// Кто-то успел сохранить такую же песню параллельно:
+			// сохраняем пачку по одной, чтобы найти виновника.
+			created += s.importOneByOne(ctx, results, songs, chunk)
+		default:
+			logging.FromContext(ctx).Error("failed to save songs: ", err)
+			if created == 0 {
+				return nil, fmt.Errorf("failed to save songs: %w", err)
+			}
+			markSkipped(results, songs, fresh[start:], "import interrupted by a storage error")
+			return results, nil
+		}
+	}
+
+	return results, nil
+}
+
+func (s *SongService) importOneByOne(ctx context.Context, results []domain.ImportResult, songs []*domain.Song, idx []int) int {
+	created := 0
+	for _, i := range idx {
+		err := s.songRepo.Create(ctx, songs[i])
+		switch {
+		case err == nil:
+			results[i] = domain.ImportResult{Status: domain.ImportCreated, Song: songs[i]}
+			created++
+		case errors.Is(err, domain.ErrConflict):
+			results[i] = domain.ImportResult{Status: domain.ImportDuplicate, Song: songs[i], Reason: "song already exists"}
+		default:
+			logging.FromContext(ctx).Error("failed to save song: ", err)
+			results[i] = domain.ImportResult{Status: domain.ImportSkipped, Song: songs[i], Reason: "failed to save song"}
+		}
+	}
+	return created
+}
+
+func markCreated(results []domain.ImportResult, songs []*domain.Song, idx []int) {
+	for _, i := range idx {
+		results[i] = domain.ImportResult{Status: domain.ImportCreated, Song: songs[i]}
+	}
+}
+
+func markSkipped(results []domain.ImportResult, songs []*domain.Song, idx []int, reason string) {
+	for _, i := range idx {
+		results[i] = domain.ImportResult{Status: domain.ImportSkipped, Song: songs[i], Reason: reason}
+	}
+}
@@ -0,0 +1,202 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"test-task/internal/domain"
+	"test-task/internal/repository"
+	"test-task/internal/services"
+	"testing"
+	"time"
+)
+
+func newSongService(t *testing.T) (domain.SongService, domain.TenantRepository, context.Context) {
+	t.Helper()
+	tenants := repository.NewMemoryTenantRepo()
+	svc := services.NewSongService(repository.NewMemorySongRepo(), tenants)
+	ctx := domain.WithActor(domain.WithTenant(context.Background(), domain.DefaultTenant), "tester")
+	return svc, tenants, ctx
+}
+
+func TestCreateSongSetsActors(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	song := &domain.Song{Group: "Muse", Song: "Uprising"}
+	if err := svc.CreateSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetSong(ctx, song.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CreatedBy != "tester" || got.UpdatedBy != "tester" {
+		t.Errorf("actors = %q/%q, want tester/tester", got.CreatedBy, got.UpdatedBy)
+	}
+}
+
+func TestCreateSongQuota(t *testing.T) {
+	svc, tenants, ctx := newSongService(t)
+
+	if err := tenants.Update(ctx, &domain.Tenant{ID: domain.DefaultTenant, Name: "Default", MaxSongs: 1, CreatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.CreateSong(ctx, &domain.Song{Group: "Muse", Song: "Uprising"}); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.CreateSong(ctx, &domain.Song{Group: "Muse", Song: "Starlight"})
+	if !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("err = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestUpdateSong(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	song := &domain.Song{Group: "Muse", Song: "Uprising", Text: "verse"}
+	if err := svc.CreateSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+
+	editor := domain.WithActor(ctx, "editor")
+	updated, err := svc.UpdateSong(editor, song.ID, &domain.Song{Song: "Starlight"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Group != "Muse" || updated.Song != "Starlight" || updated.Text != "verse" {
+		t.Errorf("updated = %+v, want only the title changed", updated)
+	}
+	if updated.CreatedBy != "tester" || updated.UpdatedBy != "editor" {
+		t.Errorf("actors = %q/%q, want tester/editor", updated.CreatedBy, updated.UpdatedBy)
+	}
+
+	if _, err := svc.UpdateSong(ctx, song.ID+100, &domain.Song{Song: "x"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("missing song: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteSong(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	song := &domain.Song{Group: "Muse", Song: "Uprising"}
+	if err := svc.CreateSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteSong(ctx, song.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteSong(ctx, song.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("second delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetTextBySongID(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	song := &domain.Song{Group: "Muse", Song: "Uprising", Text: "one\ntwo\nthree"}
+	if err := svc.CreateSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		page, limit int
+		want        []string
+	}{
+		{1, 2, []string{"one", "two"}},
+		{2, 2, []string{"three"}},
+		{3, 2, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := svc.GetTextBySongID(ctx, song.ID, tt.page, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page %d limit %d: got %q, want %q", tt.page, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestImportSongs(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	if err := svc.CreateSong(ctx, &domain.Song{Group: "Muse", Song: "Uprising"}); err != nil {
+		t.Fatal(err)
+	}
+
+	songs := []*domain.Song{
+		{Group: "Muse", Song: "Uprising"},
+		{Group: "Muse", Song: "Starlight"},
+		{Group: "Muse", Song: "Starlight"},
+	}
+	results, err := svc.ImportSongs(ctx, songs, domain.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []domain.ImportStatus{domain.ImportDuplicate, domain.ImportCreated, domain.ImportDuplicate}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("results[%d] = %s (%s), want %s", i, r.Status, r.Reason, want[i])
+		}
+	}
+	if n, _ := svc.CountSongs(ctx, domain.SongFilter{}); n != 2 {
+		t.Errorf("count = %d, want 2", n)
+	}
+}
+
+func TestImportSongsAtomicAndDryRun(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	dup := []*domain.Song{
+		{Group: "Muse", Song: "Uprising"},
+		{Group: "Muse", Song: "Uprising"},
+	}
+	results, err := svc.ImportSongs(ctx, dup, domain.ImportOptions{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != domain.ImportSkipped || results[1].Status != domain.ImportDuplicate {
+		t.Errorf("atomic statuses = %s/%s, want skipped/duplicate", results[0].Status, results[1].Status)
+	}
+
+	dry := []*domain.Song{{Group: "Muse", Song: "Starlight"}}
+	results, err = svc.ImportSongs(ctx, dry, domain.ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != domain.ImportCreated {
+		t.Errorf("dry run status = %s, want created", results[0].Status)
+	}
+
+	if n, _ := svc.CountSongs(ctx, domain.SongFilter{}); n != 0 {
+		t.Errorf("count = %d, want 0: nothing should be saved", n)
+	}
+}
+
+func TestUpdateSongInfo(t *testing.T) {
+	svc, _, ctx := newSongService(t)
+
+	song := &domain.Song{Group: "Muse", Song: "Uprising"}
+	if err := svc.CreateSong(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	// Обогащение работает с копией песни, снятой при постановке в очередь.
+	stale := *song
+	if _, err := svc.UpdateSong(ctx, song.ID, &domain.Song{Song: "Starlight"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info := domain.SongInfo{Text: "verse", ReleaseDate: time.Date(2009, time.September, 7, 0, 0, 0, 0, time.UTC), Link: "https://example.com"}
+	if err := svc.UpdateSongInfo(ctx, &stale, info); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.GetSong(ctx, song.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Song != "Starlight" || got.Text != info.Text || !got.ReleaseDate.Equal(info.ReleaseDate) || got.Link != info.Link {
+		t.Errorf("song = %+v, want the new title and the fetched info", got)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"test-task/internal/domain"
+	"test-task/pkg/logging"
+	"time"
+)
+
+// ID арендатора попадает в заголовки, логи и claims токенов,
+// поэтому только строчные латинские буквы, цифры и дефис.
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+const maxTenantNameLen = 100
+
+type TenantService struct {
+	tenantRepo domain.TenantRepository
+	now        func() time.Time
+}
+
+func NewTenantService(tenantRepo domain.TenantRepository) domain.TenantService {
+	return &TenantService{tenantRepo: tenantRepo, now: time.Now}
+}
+
+func (s *TenantService) CreateTenant(ctx context.Context, tenant *domain.Tenant) error {
+	if !tenantIDPattern.MatchString(tenant.ID) {
+		return fmt.Errorf("%w: tenant id must be 1 to 64 lowercase letters, digits or dashes", domain.ErrInvalidInput)
+	}
+	if err := validateTenant(tenant); err != nil {
+		return err
+	}
+	tenant.CreatedAt = s.now().UTC()
+
+	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return fmt.Errorf("tenant %s already exists: %w", tenant.ID, domain.ErrConflict)
+		}
+		logging.FromContext(ctx).Error("failed to save tenant: ", err)
+		return fmt.Errorf("failed to save tenant: %w", err)
+	}
+	return nil
+}
+
+func (s *TenantService) GetTenant(ctx context.Context, id string) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("tenant %s %w", id, domain.ErrNotFound)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to look up tenant: ", err)
+		return nil, fmt.Errorf("failed to look up tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+func (s *TenantService) ListTenants(ctx context.Context) ([]domain.Tenant, error) {
+	tenants, err := s.tenantRepo.List(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list tenants: ", err)
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+func (s *TenantService) UpdateTenant(ctx context.Context, tenant *domain.Tenant) error {
+	if err := validateTenant(tenant); err != nil {
+		return err
+	}
+	if err := s.tenantRepo.Update(ctx, tenant); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("tenant %s %w", tenant.ID, domain.ErrNotFound)
+		}
+		logging.FromContext(ctx).Error("failed to update tenant: ", err)
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+	return nil
+}
+
+func validateTenant(tenant *domain.Tenant) error {
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	if tenant.Name == "" || len(tenant.Name) > maxTenantNameLen {
+		return fmt.Errorf("%w: tenant name must be 1 to %d characters long", domain.ErrInvalidInput, maxTenantNameLen)
+	}
+	if tenant.MaxSongs < 0 {
+		return fmt.Errorf("%w: song quota must not be negative", domain.ErrInvalidInput)
+	}
+	if tenant.LyricsURL != "" {
+		if u, err := url.Parse(tenant.LyricsURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: lyrics provider must be an http(s) URL, got %q", domain.ErrInvalidInput, tenant.LyricsURL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"test-task/internal/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("test-task/internal/services")
+
+// tracedSongService оборачивает каждый метод сервиса в span, чтобы в трассе
+// было видно, сколько времени заняла бизнес-логика вокруг запросов к БД.
+type tracedSongService struct {
+	next domain.SongService
+}
+
+// NewTracedSongService добавляет к сервису трассировку OpenTelemetry.
+func NewTracedSongService(next domain.SongService) domain.SongService {
+	return &tracedSongService{next: next}
+}
+
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "SongService."+method, trace.WithAttributes(attrs...))
+}
+
+// end закрывает span. Ошибки клиента (нет песни, дубликат, клиент ушёл
+// до ответа) записываются событием, но span ошибочным не помечают:
+// сервис отработал штатно.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !isClientError(err) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+func isClientError(err error) bool {
+	return errors.Is(err, domain.ErrNotFound) ||
+		errors.Is(err, domain.ErrConflict) ||
+		errors.Is(err, domain.ErrValidation) ||
+		errors.Is(err, domain.ErrInvalidInput) ||
+		errors.Is(err, context.Canceled)
+}
+
+func (t *tracedSongService) GetSongs(ctx context.Context, group, song string, page, limit int) ([]domain.Song, error) {
+	ctx, span := start(ctx, "GetSongs", attribute.Int("page", page), attribute.Int("limit", limit))
+	songs, err := t.next.GetSongs(ctx, group, song, page, limit)
+	span.SetAttributes(attribute.Int("songs.count", len(songs)))
+	end(span, err)
+	return songs, err
+}
+
+func (t *tracedSongService) GetSong(ctx context.Context, id int) (*domain.Song, error) {
+	ctx, span := start(ctx, "GetSong", attribute.Int("song.id", id))
+	song, err := t.next.GetSong(ctx, id)
+	end(span, err)
+	return song, err
+}
+
+func (t *tracedSongService) GetTextBySongID(ctx context.Context, id, page, limit int) ([]string, error) {
+	ctx, span := start(ctx, "GetTextBySongID", attribute.Int("song.id", id))
+	text, err := t.next.GetTextBySongID(ctx, id, page, limit)
+	end(span, err)
+	return text, err
+}
+
+func (t *tracedSongService) DeleteSong(ctx context.Context, id int) error {
+	ctx, span := start(ctx, "DeleteSong", attribute.Int("song.id", id))
+	err := t.next.DeleteSong(ctx, id)
+	end(span, err)
+	return err
+}
+
+func (t *tracedSongService) UpdateSong(ctx context.Context, id int, updateSong *domain.Song) (*domain.Song, error) {
+	ctx, span := start(ctx, "UpdateSong", attribute.Int("song.id", id))
+	song, err := t.next.UpdateSong(ctx, id, updateSong)
+	end(span, err)
+	return song, err
+}
+
+func (t *tracedSongService) CreateSong(ctx context.Context, song *domain.Song) error {
+	ctx, span := start(ctx, "CreateSong")
+	err := t.next.CreateSong(ctx, song)
+	span.SetAttributes(attribute.Int("song.id", song.ID))
+	end(span, err)
+	return err
+}
+
+func (t *tracedSongService) UpdateSongInfo(ctx context.Context, song *domain.Song, info domain.SongInfo) error {
+	ctx, span := start(ctx, "UpdateSongInfo", attribute.Int("song.id", song.ID))
+	err := t.next.UpdateSongInfo(ctx, song, info)
+	end(span, err)
+	return err
+}
+
+func (t *tracedSongService) ImportSongs(ctx context.Context, songs []*domain.Song, opts domain.ImportOptions) ([]domain.ImportResult, error) {
+	ctx, span := start(ctx, "ImportSongs",
+		attribute.Int("songs.count", len(songs)),
+		attribute.Bool("import.atomic", opts.Atomic),
+		attribute.Bool("import.dry_run", opts.DryRun),
+	)
+	results, err := t.next.ImportSongs(ctx, songs, opts)
+	end(span, err)
+	return results, err
+}
+
+func (t *tracedSongService) ExportSongs(ctx context.Context, filter domain.SongFilter, fn func(*domain.Song) error) error {
+	ctx, span := start(ctx, "ExportSongs")
+	err := t.next.ExportSongs(ctx, filter, fn)
+	end(span, err)
+	return err
+}
+
+func (t *tracedSongService) CountSongs(ctx context.Context, filter domain.SongFilter) (int64, error) {
+	ctx, span := start(ctx, "CountSongs")
+	count, err := t.next.CountSongs(ctx, filter)
+	end(span, err)
+	return count, err
+}
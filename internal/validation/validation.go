@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"test-task/internal/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Границы допустимой даты релиза: ничего раньше начала звукозаписи
+// и не дальше года вперёд (анонсированные релизы).
+var (
+	minReleaseDate  = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxReleaseAhead = 365 * 24 * time.Hour
+)
+
+// Normalizer реализуют DTO, которым нужно привести данные к каноничному
+// виду (обрезать пробелы и т.п.) до проверки.
+type Normalizer interface {
+	Normalize()
+}
+
+// FieldError описывает ошибку в одном поле запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors - список ошибок по полям, возвращается целиком,
+// чтобы клиент увидел все невалидные поля за один запрос.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() error {
+	return domain.ErrValidation
+}
+
+var once sync.Once
+
+// setup регистрирует собственные правила в валидаторе gin
+// и заставляет его называть поля так же, как они названы в JSON.
+func setup() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	v.RegisterValidation("releasedate", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		if !ok {
+			return false
+		}
+		if t.IsZero() {
+			return true
+		}
+		return !t.Before(minReleaseDate) && !t.After(time.Now().Add(maxReleaseAhead))
+	})
+}
+
+// BindJSON разбирает тело запроса в obj, нормализует его и проверяет
+// по тегам binding. Разбор строгий: неизвестные поля и данные после
+// объекта - ошибка, а не молча отброшенный ввод. Ошибки проверки
+// возвращаются как Errors, все остальные ошибки оборачивают
+// domain.ErrInvalidInput.
+func BindJSON(c *gin.Context, obj any) error {
+	once.Do(setup)
+
+	dec := NewDecoder(c.Request.Body)
+	if err := dec.Decode(obj); err != nil {
+		switch {
+		case errors.Is(err, io.EOF):
+			return fmt.Errorf("%w: request body is empty", domain.ErrInvalidInput)
+		case errors.Is(err, domain.ErrTooLarge):
+			return err
+		}
+		return fmt.Errorf("%w: invalid JSON: %v", domain.ErrInvalidInput, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: invalid JSON: unexpected data after the object", domain.ErrInvalidInput)
+	}
+
+	return Validate(obj)
+}
+
+// NewDecoder - декодер JSON из тела запроса, не принимающий неизвестные поля.
+func NewDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	return dec
+}
+
+// UnknownField сообщает, что err - это поле, которого нет в DTO.
+// encoding/json не выделяет для неё отдельный тип, только текст.
+func UnknownField(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "json: unknown field ")
+}
+
+// Validate нормализует и проверяет уже разобранную структуру.
+func Validate(obj any) error {
+	once.Do(setup)
+
+	if n, ok := obj.(Normalizer); ok {
+		n.Normalize()
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) {
+			return fromValidator(verrs)
+		}
+		return err
+	}
+	return nil
+}
+
+func fromValidator(verrs validator.ValidationErrors) Errors {
+	errs := make(Errors, 0, len(verrs))
+	for _, fe := range verrs {
+		errs = append(errs, FieldError{
+			Field:   fe.Field(),
+			Message: message(fe),
+		})
+	}
+	return errs
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "http_url", "url":
+		return "must be a valid http(s) URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "releasedate":
+		return fmt.Sprintf("must be between %s and one year from now", minReleaseDate.Format(time.DateOnly))
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+	}
+}
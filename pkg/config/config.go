@@ -0,0 +1,645 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"test-task/pkg/logging"
+	"time"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	// Демо-режим: песни хранятся в памяти процесса, БД не нужна.
+	DriverMemory = "memory"
+)
+
+// Config - все настройки приложения. Собирается один раз при старте
+// из нескольких источников, см. Load.
+//
+// Теги: yaml - ключ в файле (и в YAML, и в TOML), env - переменная
+// окружения, secret - значение скрывается при печати, usage - подсказка
+// для флага. Имя флага получается из ключа: database.host -> --database-host.
+type Config struct {
+	Server      Server      `yaml:"server"`
+	Database    Database    `yaml:"database"`
+	ExternalAPI ExternalAPI `yaml:"external_api"`
+	Enrichment  Enrichment  `yaml:"enrichment"`
+	API         API         `yaml:"api"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Logging     Logging     `yaml:"logging"`
+	Auth        Auth        `yaml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	CORS        CORS        `yaml:"cors"`
+}
+
+// Server - настройки HTTP-сервера.
+type Server struct {
+	Port         string        `yaml:"port" env:"PORT" usage:"HTTP port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" usage:"max time to read a request"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" usage:"max time to write a response"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" usage:"keep-alive timeout, 0 - same as read timeout"`
+	// За это время после SIGINT/SIGTERM сервер дожидается текущих
+	// запросов и фонового обогащения.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" usage:"time to finish requests and enrichment on shutdown"`
+	// Сколько /readyz ждёт ответа от каждой зависимости.
+	HealthTimeout time.Duration `yaml:"health_timeout" env:"SERVER_HEALTH_TIMEOUT" usage:"timeout of each readiness check"`
+	// Адрес клиента берётся из X-Forwarded-For только за этими прокси;
+	// иначе любой клиент подставит чужой адрес и обойдёт ограничения.
+	TrustedProxies string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" usage:"comma-separated proxy IPs or CIDRs allowed to set X-Forwarded-For"`
+	// Strict-Transport-Security; включать, только если API доступен
+	// снаружи исключительно по HTTPS.
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age" env:"SERVER_HSTS_MAX_AGE" usage:"Strict-Transport-Security max-age, 0 - header is not sent"`
+}
+
+// Proxies разбирает TrustedProxies в список адресов.
+func (s Server) Proxies() []string {
+	return splitList(s.TrustedProxies)
+}
+
+// Database - подключение к хранилищу песен.
+type Database struct {
+	Driver   string `yaml:"driver" env:"DB_DRIVER" usage:"postgres, sqlite or memory"`
+	Host     string `yaml:"host" env:"DB_HOST" usage:"postgres host"`
+	Port     string `yaml:"port" env:"DB_PORT" usage:"postgres port"`
+	User     string `yaml:"user" env:"DB_USER" usage:"postgres user"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true" usage:"postgres password"`
+	Name     string `yaml:"name" env:"DB_NAME" usage:"postgres database name"`
+	Path     string `yaml:"path" env:"DB_PATH" usage:"sqlite file, :memory: - in-memory database"`
+
+	// TLS для postgres, значения как у sslmode в libpq.
+	SSLMode     string `yaml:"ssl_mode" env:"DB_SSLMODE" usage:"disable, allow, prefer, require, verify-ca or verify-full"`
+	SSLRootCert string `yaml:"ssl_root_cert" env:"DB_SSLROOTCERT" usage:"CA certificate to verify the server (verify-ca, verify-full)"`
+	SSLCert     string `yaml:"ssl_cert" env:"DB_SSLCERT" usage:"client certificate"`
+	SSLKey      string `yaml:"ssl_key" env:"DB_SSLKEY" usage:"client certificate key"`
+
+	// Пул соединений; для реплики действуют те же ограничения.
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"max open connections, 0 - unlimited"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"max idle connections kept in the pool"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"close connections older than this, 0 - never"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"close connections idle for this long, 0 - never"`
+
+	// Сколько при старте повторять попытки подключения, пока БД поднимается.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT" usage:"keep retrying to connect on startup this long, 0 - single attempt"`
+
+	// Реплика только для чтения (postgres): на неё уходят списки песен
+	// и чтение по ID, всё остальное - на основную БД.
+	ReplicaDSN string `yaml:"replica_dsn" env:"DB_REPLICA_DSN" secret:"true" usage:"postgres DSN of a read-only replica, empty - read from the primary"`
+}
+
+// SSL-режимы postgres.
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// ExternalAPI - сервис, из которого берутся тексты, даты релиза и ссылки.
+type ExternalAPI struct {
+	URL     string        `yaml:"url" env:"EXTERNAL_API_URL" usage:"base URL of the song info API"`
+	Timeout time.Duration `yaml:"timeout" env:"EXTERNAL_API_TIMEOUT" usage:"timeout of a single request to the API"`
+	// После BreakerThreshold ошибок подряд запросы к API прекращаются
+	// на BreakerCooldown.
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"EXTERNAL_API_BREAKER_THRESHOLD" usage:"consecutive failures before requests to the API are paused"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"EXTERNAL_API_BREAKER_COOLDOWN" usage:"pause after the API keeps failing"`
+	// Квота провайдера: не больше RateLimit запросов в секунду на адрес
+	// со всех воркеров вместе, с запасом RateBurst. Лишние запросы ждут.
+	RateLimit float64 `yaml:"rate_limit" env:"EXTERNAL_API_RATE_LIMIT" usage:"requests per second to each provider, 0 - unlimited"`
+	RateBurst int     `yaml:"rate_burst" env:"EXTERNAL_API_RATE_BURST" usage:"requests to a provider allowed at once above the rate"`
+}
+
+// Enrichment - фоновая очередь обогащения песен.
+type Enrichment struct {
+	Workers   int `yaml:"workers" env:"ENRICHMENT_WORKERS" usage:"number of parallel requests to the external API"`
+	QueueSize int `yaml:"queue_size" env:"ENRICHMENT_QUEUE_SIZE" usage:"songs waiting for enrichment before new ones are dropped"`
+	// Куда сохранить недообогащённые песни при остановке; пустое значение -
+	// только записать их ID в лог.
+	CheckpointFile string `yaml:"checkpoint_file" env:"ENRICHMENT_CHECKPOINT_FILE" usage:"file to save unfinished enrichment on shutdown and resume from on start"`
+}
+
+// API - поведение HTTP-обработчиков.
+type API struct {
+	DefaultPageSize int `yaml:"default_page_size" env:"API_DEFAULT_PAGE_SIZE" usage:"page size when limit is not given"`
+	MaxPageSize     int `yaml:"max_page_size" env:"API_MAX_PAGE_SIZE" usage:"largest allowed limit"`
+	// По истечении таймаута контекст запроса отменяется и запросы к БД
+	// прерываются. Выгрузке и пакетному импорту нужно больше времени.
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"API_REQUEST_TIMEOUT" usage:"time limit of a regular request"`
+	ExportTimeout  time.Duration `yaml:"export_timeout" env:"API_EXPORT_TIMEOUT" usage:"time limit of /songs/export"`
+	BulkTimeout    time.Duration `yaml:"bulk_timeout" env:"API_BULK_TIMEOUT" usage:"time limit of /songs/bulk"`
+	// Тела больше лимита отклоняются с 413, не дочитываясь до конца.
+	MaxBodyKB     int `yaml:"max_body_kb" env:"API_MAX_BODY_KB" usage:"largest request body in KB"`
+	BulkMaxBodyMB int `yaml:"bulk_max_body_mb" env:"API_BULK_MAX_BODY_MB" usage:"largest /songs/bulk body in MB"`
+	// Маршруты без версии (/song, /verse/:id) работают как устаревшие
+	// псевдонимы /api/v1 и отвечают с заголовками Deprecation и Sunset.
+	LegacyRoutes bool   `yaml:"legacy_routes" env:"API_LEGACY_ROUTES" usage:"serve the unversioned routes as deprecated aliases of /api/v1"`
+	LegacySunset string `yaml:"legacy_sunset" env:"API_LEGACY_SUNSET" usage:"date (YYYY-MM-DD) the unversioned routes are removed, sent in Sunset"`
+}
+
+// Sunset - дата отключения маршрутов без версии.
+func (a API) Sunset() time.Time {
+	t, _ := time.Parse(time.DateOnly, a.LegacySunset)
+	return t
+}
+
+// Tracing - экспорт трасс OpenTelemetry.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" usage:"none, stdout or otlp"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" usage:"OTLP/HTTP collector host:port, empty - OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" usage:"send traces to the collector without TLS"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" usage:"service.name of exported spans"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" usage:"share of new traces to record, from 0 to 1"`
+}
+
+const (
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// Auth - доступ к API.
+type Auth struct {
+	// Без проверки все маршруты открыты; только для локальной разработки.
+	Enabled bool `yaml:"enabled" env:"AUTH_ENABLED" usage:"require API keys or user tokens, false - open API for local development"`
+	// Пользователи входят через провайдера удостоверений (OIDC) и приходят
+	// с JWT. Ключи подписи берутся из JWKS по URL или из файла; без них
+	// принимаются только API-ключи.
+	JWKSURL  string `yaml:"jwks_url" env:"AUTH_JWKS_URL" usage:"JWKS URL of the identity provider"`
+	JWKSFile string `yaml:"jwks_file" env:"AUTH_JWKS_FILE" usage:"local JWKS file instead of the URL, e.g. for tests"`
+	// JWKS по URL перечитывается с этим интервалом и при незнакомом kid.
+	JWKSRefresh time.Duration `yaml:"jwks_refresh" env:"AUTH_JWKS_REFRESH" usage:"how often to refetch the JWKS URL"`
+	Issuer      string        `yaml:"issuer" env:"AUTH_ISSUER" usage:"expected iss claim, required with JWT"`
+	Audience    string        `yaml:"audience" env:"AUTH_AUDIENCE" usage:"expected aud claim, required with JWT"`
+	// Роли ищутся в этом claim; вложенные claim через точку, например
+	// realm_access.roles. Значение - массив строк или строка через пробел.
+	RolesClaim string `yaml:"roles_claim" env:"AUTH_ROLES_CLAIM" usage:"claim with user roles, dots for nested claims"`
+	// Переименование ролей провайдера в роли приложения (viewer, editor,
+	// admin): librarians=editor,staff=viewer. Роли без пары берутся как есть.
+	RoleMap string `yaml:"role_map" env:"AUTH_ROLE_MAP" usage:"provider=app role pairs, comma-separated"`
+	// Арендатор пользователя; без этого claim пользователь работает
+	// с арендатором по умолчанию.
+	TenantClaim string        `yaml:"tenant_claim" env:"AUTH_TENANT_CLAIM" usage:"claim with the user's tenant, dots for nested claims"`
+	Leeway      time.Duration `yaml:"leeway" env:"AUTH_LEEWAY" usage:"allowed clock skew for exp and nbf"`
+}
+
+// RateLimit - ограничение частоты запросов к API от одного клиента:
+// API-ключа или пользователя, а без учётных данных - IP-адреса. Лимит
+// задаётся скоростью в запросах в секунду и запасом (burst), который
+// можно потратить сразу. У каждой группы маршрутов свой счётчик.
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env:"RATE_LIMIT_ENABLED" usage:"limit request rate per client"`
+	// Чтение песен и текстов.
+	Read      float64 `yaml:"read" env:"RATE_LIMIT_READ" usage:"song reads per second per client"`
+	ReadBurst int     `yaml:"read_burst" env:"RATE_LIMIT_READ_BURST" usage:"song reads allowed at once above the rate"`
+	// Изменение и удаление песен.
+	Write      float64 `yaml:"write" env:"RATE_LIMIT_WRITE" usage:"song updates and deletions per second per client"`
+	WriteBurst int     `yaml:"write_burst" env:"RATE_LIMIT_WRITE_BURST" usage:"song updates and deletions allowed at once above the rate"`
+	// POST /song: каждая новая песня - ещё и запрос к внешнему API.
+	Create      float64 `yaml:"create" env:"RATE_LIMIT_CREATE" usage:"new songs per second per client"`
+	CreateBurst int     `yaml:"create_burst" env:"RATE_LIMIT_CREATE_BURST" usage:"new songs allowed at once above the rate"`
+	// Пакетный импорт и выгрузка - самые тяжёлые запросы.
+	Bulk      float64 `yaml:"bulk" env:"RATE_LIMIT_BULK" usage:"bulk imports and exports per second per client"`
+	BulkBurst int     `yaml:"bulk_burst" env:"RATE_LIMIT_BULK_BURST" usage:"bulk imports and exports allowed at once above the rate"`
+}
+
+// CORS - доступ к API из браузера с других доменов. Без Origins
+// заголовки CORS не отправляются и браузер такие запросы блокирует.
+type CORS struct {
+	Origins     string `yaml:"origins" env:"CORS_ORIGINS" usage:"comma-separated origins allowed to call the API, * - any"`
+	Methods     string `yaml:"methods" env:"CORS_METHODS" usage:"comma-separated methods allowed in cross-origin requests"`
+	Headers     string `yaml:"headers" env:"CORS_HEADERS" usage:"comma-separated request headers allowed in cross-origin requests"`
+	Credentials bool   `yaml:"credentials" env:"CORS_CREDENTIALS" usage:"allow cookies and Authorization in cross-origin requests"`
+	// Сколько браузер может не повторять preflight-запрос.
+	MaxAge time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" usage:"how long browsers may cache a preflight response"`
+}
+
+// AllowedOrigins разбирает Origins в список.
+func (c CORS) AllowedOrigins() []string {
+	return splitList(c.Origins)
+}
+
+// AllowedMethods разбирает Methods в список.
+func (c CORS) AllowedMethods() []string {
+	return splitList(c.Methods)
+}
+
+// AllowedHeaders разбирает Headers в список.
+func (c CORS) AllowedHeaders() []string {
+	return splitList(c.Headers)
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// JWTEnabled - настроена ли проверка пользовательских JWT.
+func (a Auth) JWTEnabled() bool {
+	return a.JWKSURL != "" || a.JWKSFile != ""
+}
+
+// RoleMapping разбирает RoleMap: роль провайдера -> роль приложения.
+func (a Auth) RoleMapping() map[string]string {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(a.RoleMap, ",") {
+		if from, to, ok := strings.Cut(pair, "="); ok {
+			mapping[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+	return mapping
+}
+
+// Logging - журнал приложения.
+type Logging struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" usage:"trace, debug, info, warn or error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" usage:"text or json"`
+	// Куда писать журнал: stdout, file или оба через запятую; none - никуда.
+	Output string `yaml:"output" env:"LOG_OUTPUT" usage:"stdout, file, stdout,file or none"`
+	File   string `yaml:"file" env:"LOG_FILE" usage:"log file for the file output"`
+	// Файл ротируется по размеру и, если задано, по времени. Старые файлы
+	// удаляются по возрасту и по количеству.
+	MaxSize     int           `yaml:"max_size" env:"LOG_MAX_SIZE" usage:"rotate the log file after this many megabytes"`
+	RotateEvery time.Duration `yaml:"rotate_every" env:"LOG_ROTATE_EVERY" usage:"also rotate the log file this often, 0 - by size only"`
+	MaxAgeDays  int           `yaml:"max_age_days" env:"LOG_MAX_AGE_DAYS" usage:"days to keep rotated log files, 0 - no limit"`
+	MaxBackups  int           `yaml:"max_backups" env:"LOG_MAX_BACKUPS" usage:"rotated log files to keep, 0 - no limit"`
+	Compress    bool          `yaml:"compress" env:"LOG_COMPRESS" usage:"gzip rotated log files"`
+}
+
+const (
+	LogText = "text"
+	LogJSON = "json"
+
+	LogStdout = "stdout"
+	LogFile   = "file"
+	LogNone   = "none"
+)
+
+// Outputs разбирает Output в список назначений. Для none список пуст.
+func (l Logging) Outputs() []string {
+	if strings.TrimSpace(l.Output) == LogNone {
+		return nil
+	}
+	var outputs []string
+	for _, o := range strings.Split(l.Output, ",") {
+		outputs = append(outputs, strings.TrimSpace(o))
+	}
+	return outputs
+}
+
+// Default возвращает настройки по умолчанию.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			HealthTimeout:   2 * time.Second,
+		},
+		Database: Database{
+			Driver:          DriverPostgres,
+			Port:            "5432",
+			Path:            "songs.db",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  30 * time.Second,
+		},
+		ExternalAPI: ExternalAPI{
+			Timeout:          10 * time.Second,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+			RateBurst:        1,
+		},
+		Enrichment: Enrichment{
+			Workers:   4,
+			QueueSize: 1024,
+		},
+		API: API{
+			DefaultPageSize: 10,
+			MaxPageSize:     100,
+			RequestTimeout:  10 * time.Second,
+			ExportTimeout:   10 * time.Minute,
+			BulkTimeout:     2 * time.Minute,
+			MaxBodyKB:       64,
+			BulkMaxBodyMB:   32,
+			LegacyRoutes:    true,
+			LegacySunset:    "2027-04-19",
+		},
+		Tracing: Tracing{
+			Exporter:    TracingNone,
+			ServiceName: "song-library",
+			SampleRatio: 1,
+		},
+		Logging: Logging{
+			Level:      "info",
+			Format:     LogText,
+			Output:     LogStdout,
+			File:       "logs/all.log",
+			MaxSize:    100,
+			MaxAgeDays: 30,
+			MaxBackups: 10,
+		},
+		Auth: Auth{
+			Enabled:     true,
+			JWKSRefresh: time.Hour,
+			RolesClaim:  "roles",
+			TenantClaim: "tenant",
+			Leeway:      time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled:     true,
+			Read:        20,
+			ReadBurst:   40,
+			Write:       5,
+			WriteBurst:  10,
+			Create:      1,
+			CreateBurst: 5,
+			Bulk:        0.1,
+			BulkBurst:   2,
+		},
+		CORS: CORS{
+			Methods: "GET,POST,PATCH,PUT,DELETE",
+			Headers: "Authorization,Content-Type,X-API-Key,X-Tenant-ID,X-Request-ID",
+			MaxAge:  10 * time.Minute,
+		},
+	}
+}
+
+// Validate проверяет настройки целиком и возвращает все ошибки сразу.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+
+	if p, err := strconv.Atoi(c.Server.Port); err != nil || p < 1 || p > 65535 {
+		fail("server.port", "must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 {
+		fail("server.read_timeout", "must be positive")
+	}
+	if c.Server.WriteTimeout <= 0 {
+		fail("server.write_timeout", "must be positive")
+	}
+	if c.Server.IdleTimeout < 0 {
+		fail("server.idle_timeout", "must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("server.shutdown_timeout", "must be positive")
+	}
+	if c.Server.HealthTimeout <= 0 {
+		fail("server.health_timeout", "must be positive")
+	}
+	if c.Server.HSTSMaxAge < 0 {
+		fail("server.hsts_max_age", "must not be negative")
+	}
+	for _, p := range c.Server.Proxies() {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			fail("server.trusted_proxies", "must be a list of IPs or CIDRs, got %q", p)
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverPostgres:
+		required := []struct{ key, value string }{
+			{"database.host", c.Database.Host},
+			{"database.port", c.Database.Port},
+			{"database.user", c.Database.User},
+			{"database.name", c.Database.Name},
+		}
+		for _, r := range required {
+			if r.value == "" {
+				fail(r.key, "is required for the %s driver", DriverPostgres)
+			}
+		}
+		if !slices.Contains(SSLModes, c.Database.SSLMode) {
+			fail("database.ssl_mode", "must be one of %s, got %q", strings.Join(SSLModes, ", "), c.Database.SSLMode)
+		}
+		if (c.Database.SSLCert == "") != (c.Database.SSLKey == "") {
+			fail("database.ssl_key", "database.ssl_cert and database.ssl_key must be set together")
+		}
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			fail("database.path", "is required for the %s driver", DriverSQLite)
+		}
+	case DriverMemory:
+	default:
+		fail("database.driver", "must be %s, %s or %s, got %q", DriverPostgres, DriverSQLite, DriverMemory, c.Database.Driver)
+	}
+	if c.Database.ReplicaDSN != "" && c.Database.Driver != DriverPostgres {
+		fail("database.replica_dsn", "is supported only by the %s driver", DriverPostgres)
+	}
+	if c.Database.MaxOpenConns < 0 {
+		fail("database.max_open_conns", "must not be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		fail("database.max_idle_conns", "must not be negative")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		fail("database.conn_max_lifetime", "must not be negative")
+	}
+	if c.Database.ConnMaxIdleTime < 0 {
+		fail("database.conn_max_idle_time", "must not be negative")
+	}
+	if c.Database.ConnectTimeout < 0 {
+		fail("database.connect_timeout", "must not be negative")
+	}
+
+	// Без адреса API сервис работает, просто песни не обогащаются.
+	if c.ExternalAPI.URL != "" {
+		if u, err := url.Parse(c.ExternalAPI.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("external_api.url", "must be an http(s) URL, got %q", c.ExternalAPI.URL)
+		}
+	}
+	if c.ExternalAPI.Timeout <= 0 {
+		fail("external_api.timeout", "must be positive")
+	}
+	if c.ExternalAPI.BreakerThreshold < 1 {
+		fail("external_api.breaker_threshold", "must be positive")
+	}
+	if c.ExternalAPI.BreakerCooldown <= 0 {
+		fail("external_api.breaker_cooldown", "must be positive")
+	}
+	if c.ExternalAPI.RateLimit < 0 {
+		fail("external_api.rate_limit", "must not be negative")
+	}
+	if c.ExternalAPI.RateLimit > 0 && c.ExternalAPI.RateBurst < 1 {
+		fail("external_api.rate_burst", "must be positive")
+	}
+
+	if c.Enrichment.Workers < 1 {
+		fail("enrichment.workers", "must be positive")
+	}
+	if c.Enrichment.QueueSize < 1 {
+		fail("enrichment.queue_size", "must be positive")
+	}
+
+	if c.API.DefaultPageSize < 1 {
+		fail("api.default_page_size", "must be positive")
+	}
+	if c.API.MaxPageSize < c.API.DefaultPageSize {
+		fail("api.max_page_size", "must not be less than api.default_page_size")
+	}
+	if c.API.RequestTimeout <= 0 {
+		fail("api.request_timeout", "must be positive")
+	}
+	// Выгрузка и массовое добавление сами отодвигают таймауты сервера,
+	// остальные маршруты должны в них укладываться: иначе сервер оборвёт
+	// соединение раньше, чем запрос закончится.
+	if limit := min(c.Server.ReadTimeout, c.Server.WriteTimeout); c.API.RequestTimeout > limit {
+		fail("api.request_timeout", "must not exceed server.read_timeout and server.write_timeout (%s)", limit)
+	}
+	if c.API.ExportTimeout <= 0 {
+		fail("api.export_timeout", "must be positive")
+	}
+	if c.API.BulkTimeout <= 0 {
+		fail("api.bulk_timeout", "must be positive")
+	}
+	if c.API.MaxBodyKB < 1 {
+		fail("api.max_body_kb", "must be positive")
+	}
+	if c.API.BulkMaxBodyMB < 1 {
+		fail("api.bulk_max_body_mb", "must be positive")
+	}
+	if _, err := time.Parse(time.DateOnly, c.API.LegacySunset); c.API.LegacyRoutes && err != nil {
+		fail("api.legacy_sunset", "must be a date like 2027-04-19, got %q", c.API.LegacySunset)
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingNone, TracingStdout, TracingOTLP:
+	default:
+		fail("tracing.exporter", "must be %s, %s or %s, got %q", TracingNone, TracingStdout, TracingOTLP, c.Tracing.Exporter)
+	}
+	if c.Tracing.ServiceName == "" {
+		fail("tracing.service_name", "is required")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	if !slices.Contains(logging.Levels, c.Logging.Level) {
+		fail("logging.level", "must be one of %s, got %q", strings.Join(logging.Levels, ", "), c.Logging.Level)
+	}
+	if c.Logging.Format != LogText && c.Logging.Format != LogJSON {
+		fail("logging.format", "must be %s or %s, got %q", LogText, LogJSON, c.Logging.Format)
+	}
+	for _, o := range c.Logging.Outputs() {
+		switch o {
+		case LogStdout:
+		case LogFile:
+			if c.Logging.File == "" {
+				fail("logging.file", "is required for the %s output", LogFile)
+			}
+		default:
+			fail("logging.output", "must be a list of %s and %s, or %s, got %q", LogStdout, LogFile, LogNone, c.Logging.Output)
+		}
+	}
+	if c.Logging.MaxSize < 1 {
+		fail("logging.max_size", "must be positive")
+	}
+	if c.Logging.RotateEvery < 0 {
+		fail("logging.rotate_every", "must not be negative")
+	}
+	if c.Logging.MaxAgeDays < 0 {
+		fail("logging.max_age_days", "must not be negative")
+	}
+	if c.Logging.MaxBackups < 0 {
+		fail("logging.max_backups", "must not be negative")
+	}
+
+	// Ключи хранятся в БД, в демо-режиме их негде создать: остаются
+	// только пользователи с JWT.
+	if c.Auth.Enabled && c.Database.Driver == DriverMemory && !c.Auth.JWTEnabled() {
+		fail("auth.enabled", "API keys need a database, set it to false or configure auth.jwks_url for the %s driver", DriverMemory)
+	}
+	if c.Auth.JWKSURL != "" && c.Auth.JWKSFile != "" {
+		fail("auth.jwks_file", "set either auth.jwks_url or auth.jwks_file, not both")
+	}
+	if c.Auth.JWKSURL != "" {
+		if u, err := url.Parse(c.Auth.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("auth.jwks_url", "must be an http(s) URL, got %q", c.Auth.JWKSURL)
+		}
+		if c.Auth.JWKSRefresh <= 0 {
+			fail("auth.jwks_refresh", "must be positive")
+		}
+	}
+	if c.Auth.JWTEnabled() {
+		// Без iss и aud подошёл бы токен, выданный тем же провайдером
+		// для любого другого приложения.
+		if c.Auth.Issuer == "" {
+			fail("auth.issuer", "is required with auth.jwks_url or auth.jwks_file")
+		}
+		if c.Auth.Audience == "" {
+			fail("auth.audience", "is required with auth.jwks_url or auth.jwks_file")
+		}
+		if c.Auth.RolesClaim == "" {
+			fail("auth.roles_claim", "is required")
+		}
+	}
+	for _, pair := range strings.Split(c.Auth.RoleMap, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		if from, to, ok := strings.Cut(pair, "="); !ok || strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			fail("auth.role_map", "must be a list of provider=app pairs, got %q", pair)
+		}
+	}
+	if c.Auth.Leeway < 0 {
+		fail("auth.leeway", "must not be negative")
+	}
+
+	if c.RateLimit.Enabled {
+		limits := []struct {
+			key   string
+			rate  float64
+			burst int
+		}{
+			{"read", c.RateLimit.Read, c.RateLimit.ReadBurst},
+			{"write", c.RateLimit.Write, c.RateLimit.WriteBurst},
+			{"create", c.RateLimit.Create, c.RateLimit.CreateBurst},
+			{"bulk", c.RateLimit.Bulk, c.RateLimit.BulkBurst},
+		}
+		for _, l := range limits {
+			if l.rate <= 0 {
+				fail("rate_limit."+l.key, "must be positive")
+			}
+			if l.burst < 1 {
+				fail("rate_limit."+l.key+"_burst", "must be positive")
+			}
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins() {
+		if origin == "*" {
+			// Браузер не примет ответ с учётными данными от Access-Control-Allow-Origin: *,
+			// а отражать любой Origin с ними небезопасно.
+			if c.CORS.Credentials {
+				fail("cors.credentials", "cannot be used with the * origin, list the origins instead")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail("cors.origins", "must be * or a list of scheme://host[:port], got %q", origin)
+		}
+	}
+	if len(c.CORS.AllowedOrigins()) > 0 && c.CORS.Methods == "" {
+		fail("cors.methods", "is required when cors.origins is set")
+	}
+	if c.CORS.MaxAge < 0 {
+		fail("cors.max_age", "must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name                    string
+		request, export, server time.Duration
+		wantErr                 bool
+	}{
+		{"within server timeouts", 10 * time.Second, 10 * time.Minute, 15 * time.Second, false},
+		{"equal to server timeouts", 15 * time.Second, 10 * time.Minute, 15 * time.Second, false},
+		{"longer than server timeouts", 20 * time.Second, 10 * time.Minute, 15 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.API.RequestTimeout = tt.request
+			cfg.API.ExportTimeout = tt.export
+			cfg.Server.ReadTimeout = tt.server
+			cfg.Server.WriteTimeout = tt.server
+
+			err := cfg.Validate()
+			if gotErr := err != nil && strings.Contains(err.Error(), "api.request_timeout"); gotErr != tt.wantErr {
+				t.Errorf("Validate() = %v, want an api.request_timeout error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
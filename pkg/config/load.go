@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Field описывает одну настройку во всех источниках.
+type Field struct {
+	Key     string // ключ в файле: database.host
+	Env     string // переменная окружения: DB_HOST
+	Flag    string // флаг командной строки: database-host
+	Usage   string
+	Default string
+	Secret  bool
+
+	value reflect.Value
+}
+
+// Fields перечисляет все настройки, по нему строятся флаги командной строки.
+func Fields() []Field {
+	return Default().fields()
+}
+
+// Load собирает настройки и проверяет их. Источники по возрастанию
+// приоритета: значения по умолчанию, файл path (YAML или TOML, по
+// расширению; пустой path - без файла), переменные окружения, флаги.
+// flag возвращает значение флага и true, если флаг задан явно.
+func Load(path string, flag func(name string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	if path != "" {
+		if err := loadFile(path, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields {
+		// Пустая переменная считается незаданной.
+		if v := os.Getenv(f.Env); v != "" {
+			if err := set(f.value, v); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Env, err)
+			}
+		}
+	}
+
+	if flag != nil {
+		for _, f := range fields {
+			if v, ok := flag(f.Flag); ok {
+				if err := set(f.value, v); err != nil {
+					return nil, fmt.Errorf("--%s: %w", f.Flag, err)
+				}
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Redacted возвращает копию настроек со скрытыми секретами.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	for _, f := range cp.fields() {
+		if f.Secret && f.value.String() != "" {
+			f.value.SetString(redacted)
+		}
+	}
+	return &cp
+}
+
+// String печатает настройки в YAML, секреты скрыты.
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func (c *Config) fields() []Field {
+	var fields []Field
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i).Tag.Get("yaml")
+		sv := root.Field(i)
+		for j := 0; j < sv.NumField(); j++ {
+			sf := sv.Type().Field(j)
+			key := section + "." + sf.Tag.Get("yaml")
+			fields = append(fields, Field{
+				Key:     key,
+				Env:     sf.Tag.Get("env"),
+				Flag:    strings.NewReplacer(".", "-", "_", "-").Replace(key),
+				Usage:   sf.Tag.Get("usage"),
+				Default: format(sv.Field(j)),
+				Secret:  sf.Tag.Get("secret") == "true",
+				value:   sv.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+// loadFile читает файл в общее дерево и раскладывает значения по полям
+// тем же разбором, что и для переменных окружения. Неизвестные ключи -
+// ошибка: опечатка в имени не должна молча оставлять значение по умолчанию.
+func loadFile(path string, fields []Field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	tree := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+
+	flat := map[string]any{}
+	for section, v := range tree {
+		values, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("config file %s: %s must be a section", path, section)
+		}
+		for key, value := range values {
+			flat[section+"."+key] = value
+		}
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("config file %s: unknown key %s", path, key)
+		}
+
+		var s string
+		switch v := flat[key].(type) {
+		case nil:
+		case string:
+			s = v
+		case time.Time:
+			// YAML сам разбирает даты без кавычек (2027-04-19).
+			s = v.Format(time.DateOnly)
+			if v.Hour() != 0 || v.Minute() != 0 || v.Second() != 0 {
+				s = v.Format(time.RFC3339)
+			}
+		case map[string]any, []any:
+			return fmt.Errorf("config file %s: %s must be a single value", path, key)
+		default:
+			s = fmt.Sprint(v)
+		}
+
+		if err := set(f.value, s); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func set(v reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (use e.g. 500ms, 15s, 1m)", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	default:
+		v.SetString(s)
+	}
+	return nil
+}
+
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,39 @@
+package db
+
+import "gorm.io/gorm"
+
+// registerAround вешает пару колбэков before/after на каждый вид запросов
+// gorm (create, query, update, delete, row, raw). Фабрики получают имя
+// операции, чтобы подписывать ею метрики и span-ы.
+func registerAround(gdb *gorm.DB, name string, before, after func(operation string) func(*gorm.DB)) error {
+	cb := gdb.Callback()
+	type register func(name string, fn func(*gorm.DB)) error
+	operations := []struct {
+		name          string
+		before, after register
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"row", cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+
+	for _, op := range operations {
+		if err := op.before(name+":before_"+op.name, before(op.name)); err != nil {
+			return err
+		}
+		if err := op.after(name+":after_"+op.name, after(op.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tableName(tx *gorm.DB) string {
+	if tx.Statement.Table == "" {
+		return "unknown"
+	}
+	return tx.Statement.Table
+}
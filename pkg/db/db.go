@@ -1,40 +1,187 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"os"
-	"test-task/internal/domain"
+	"strings"
+	"test-task/pkg/config"
 	"test-task/pkg/logging"
+	"time"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
-func InitDB() (db *gorm.DB, err error) {
+const (
+	DriverPostgres = config.DriverPostgres
+	DriverSQLite   = config.DriverSQLite
+	DriverMemory   = config.DriverMemory
+)
+
+// Паузы между попытками подключения при старте.
+const (
+	retryInitialDelay = 500 * time.Millisecond
+	retryMaxDelay     = 5 * time.Second
+)
+
+// InitDB подключается к БД, выбранной в cfg.Driver, повторяя попытки
+// до cfg.ConnectTimeout или отмены ctx. Если задана реплика, чтение
+// через неё включается здесь же (см. repository.SongRepo).
+// Схему InitDB не трогает, для этого есть Migrator (команда migrate up).
+func InitDB(ctx context.Context, cfg config.Database) (db *gorm.DB, err error) {
 	log := logging.GetLogger()
-	log.Info("Initializing database connection")
-
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+
+	driver := cfg.Driver
+	log.Infof("Initializing %s database connection", driver)
+
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres:
+		dialector = postgres.Open(postgresDSN(cfg))
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(cfg.Path))
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, use %s, %s or %s", driver, DriverPostgres, DriverSQLite, DriverMemory)
+	}
+
+	db, err = openWithRetry(ctx, dialector, cfg.ConnectTimeout)
 	if err != nil {
 		log.Errorf("Error connecting to DB: %v", err)
 		return nil, err
 	}
 
-	log.Info("Running migrations")
-	if err := db.AutoMigrate(&domain.Song{}); err != nil {
-		log.Errorf("Error during migration: %v", err)
+	sqlDB, err := db.DB()
+	if err != nil {
 		return nil, err
 	}
+	setPool(sqlDB, cfg)
+
+	if driver == DriverSQLite {
+		tuneSQLite(sqlDB, cfg.Path)
+	}
+
+	if cfg.ReplicaDSN != "" {
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: []gorm.Dialector{postgres.Open(cfg.ReplicaDSN)},
+		}).
+			SetMaxOpenConns(cfg.MaxOpenConns).
+			SetMaxIdleConns(cfg.MaxIdleConns).
+			SetConnMaxLifetime(cfg.ConnMaxLifetime).
+			SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+		if err := db.Use(resolver); err != nil {
+			return nil, fmt.Errorf("failed to set up read replica: %w", err)
+		}
+		log.Info("Read replica configured")
+	}
 
 	log.Info("Database initialized successfully")
 	return db, nil
 }
+
+// openWithRetry подключается к БД, при неудаче повторяя попытки с растущей
+// паузой: при совместном запуске (docker compose) БД часто поднимается
+// позже приложения.
+func openWithRetry(ctx context.Context, dialector gorm.Dialector, timeout time.Duration) (*gorm.DB, error) {
+	log := logging.GetLogger()
+	deadline := time.Now().Add(timeout)
+	delay := retryInitialDelay
+
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+		if err == nil {
+			return db, nil
+		}
+		// gorm.Open возвращает открытый пул, даже если ping не прошёл.
+		if db != nil {
+			if sqlDB, derr := db.DB(); derr == nil {
+				sqlDB.Close()
+			}
+		}
+
+		if time.Now().Add(delay).After(deadline) {
+			return nil, fmt.Errorf("database is not reachable after %d attempts: %w", attempt, err)
+		}
+		log.Warnf("Database is not reachable (attempt %d), retrying in %s: %v", attempt, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("connecting to database: %w", ctx.Err())
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+func setPool(sqlDB *sql.DB, cfg config.Database) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// postgresDSN собирает DSN в формате key=value. Значения берутся
+// в кавычки, чтобы пробелы и спецсимволы в пароле не ломали строку.
+func postgresDSN(cfg config.Database) string {
+	params := []struct{ key, value string }{
+		{"host", cfg.Host},
+		{"port", cfg.Port},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.Name},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
+
+	var parts []string
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		parts = append(parts, p.key+"="+quoteDSNValue(p.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteDSNValue(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v)
+	return "'" + v + "'"
+}
+
+func isMemory(path string) bool {
+	return path == ":memory:" || strings.Contains(path, "mode=memory")
+}
+
+func sqliteDSN(path string) string {
+	if isMemory(path) {
+		return "file::memory:?_foreign_keys=on"
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return "file:" + strings.TrimPrefix(path, "file:") + sep + "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL"
+}
+
+// tuneSQLite настраивает пул под SQLite. База в памяти живёт, пока открыто
+// соединение, а у каждого соединения она своя, поэтому соединение одно
+// и закрываться оно не должно.
+func tuneSQLite(sqlDB *sql.DB, path string) {
+	if isMemory(path) {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+}
+
+// PingReplica проверяет реплику запросом через dbresolver.
+func PingReplica(ctx context.Context, gdb *gorm.DB) error {
+	var one int
+	return gdb.WithContext(ctx).Clauses(dbresolver.Read).Raw("SELECT 1").Scan(&one).Error
+}
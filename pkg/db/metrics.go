@@ -0,0 +1,42 @@
+package db
+
+import (
+	"errors"
+	"test-task/pkg/metrics"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const metricsStartKey = "metrics:start"
+
+// InstrumentMetrics вешает на gorm колбэки, которые замеряют длительность
+// каждого запроса и считают ошибки (ErrRecordNotFound ошибкой не считается).
+func InstrumentMetrics(gdb *gorm.DB) error {
+	return registerAround(gdb, "metrics", startTimer, observe)
+}
+
+func startTimer(string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := tableName(tx)
+		metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			metrics.DBQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
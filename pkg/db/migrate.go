@@ -0,0 +1,405 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"test-task/pkg/logging"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFS embed.FS
+
+const (
+	migrationsTable = "schema_migrations"
+	// Ключ advisory lock, под которым выполняются миграции.
+	migrationLockKey int64 = 7243195301
+)
+
+// migrationDialect - различия СУБД, важные для миграций.
+type migrationDialect struct {
+	lock, unlock string // пусто, если блокировка не нужна
+	createTable  string
+}
+
+var migrationDialects = map[string]migrationDialect{
+	"postgres": {
+		lock:   "SELECT pg_advisory_lock($1)",
+		unlock: "SELECT pg_advisory_unlock($1)",
+		createTable: `CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+			version    BIGINT PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			dirty      BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	},
+	// SQLite используется локально одним процессом, а запись в файл
+	// и так сериализуется блокировкой самой SQLite.
+	"sqlite": {
+		createTable: `CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+			version    INTEGER PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			dirty      BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	},
+}
+
+var (
+	ErrSchemaDirty   = errors.New("database schema is dirty")
+	ErrSchemaPending = errors.New("database schema has unapplied migrations")
+)
+
+// Имя файла миграции: 000001_create_songs.up.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus - состояние одной миграции в БД.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// Migrator применяет встроенные в бинарник SQL-миграции и ведёт
+// их учёт в таблице schema_migrations.
+type Migrator struct {
+	gdb        *gorm.DB
+	db         *sql.DB
+	dialect    migrationDialect
+	migrations []Migration
+	log        logging.Logger
+}
+
+func NewMigrator(gdb *gorm.DB) (*Migrator, error) {
+	db, err := gdb.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	name := gdb.Dialector.Name()
+	dialect, ok := migrationDialects[name]
+	if !ok {
+		return nil, fmt.Errorf("migrations are not supported for %s", name)
+	}
+
+	migrations, err := loadMigrations(migrationFS, path.Join("migrations", name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		gdb:        gdb,
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		log:        logging.GetLogger(),
+	}, nil
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		parts := migrationFileName.FindStringSubmatch(e.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("unexpected migration file name: %s", e.Name())
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up применяет все ещё не применённые миграции и возвращает их количество.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			m.log.Infof("Applying migration %06d_%s", mig.Version, mig.Name)
+			if err := m.run(ctx, conn, mig, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down откатывает последние steps миграций, при steps <= 0 - все.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if steps > 0 && count == steps {
+				break
+			}
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			m.log.Infof("Reverting migration %06d_%s", mig.Version, mig.Name)
+			if err := m.run(ctx, conn, mig, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Force записывает, что применены ровно миграции до version включительно,
+// и снимает пометку dirty. Нужен после ручного исправления схемы.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+migrationsTable); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO "+migrationsTable+" (version, name, dirty) VALUES ($1, $2, FALSE)",
+				mig.Version, mig.Name)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// Status возвращает состояние всех известных миграций.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied := map[int64]MigrationStatus{}
+	if m.gdb.WithContext(ctx).Migrator().HasTable(migrationsTable) {
+		var err error
+		if applied, err = m.applied(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status, ok := applied[mig.Version]
+		if !ok {
+			status = MigrationStatus{Version: mig.Version}
+		}
+		status.Name = mig.Name
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Check возвращает ошибку, если схема БД не соответствует бинарнику:
+// есть неприменённые миграции или миграция, прерванная на середине.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if s.Dirty {
+			return fmt.Errorf("%w at version %d", ErrSchemaDirty, s.Version)
+		}
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%06d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %v", ErrSchemaPending, pending)
+	}
+	return nil
+}
+
+// withLock выполняет fn на отдельном соединении под advisory lock, чтобы
+// несколько экземпляров приложения не применяли миграции одновременно.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect.lock != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.lock, migrationLockKey); err != nil {
+			return fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), m.dialect.unlock, migrationLockKey); err != nil {
+				m.log.Error("releasing migration lock: ", err)
+			}
+		}()
+	}
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("creating %s: %w", migrationsTable, err)
+	}
+
+	return fn(conn)
+}
+
+// run выполняет миграцию в транзакции. Перед началом миграция помечается
+// как dirty вне транзакции: если процесс упадёт на середине, пометка
+// останется, и следующий запуск откажется работать со схемой.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, mig Migration, up bool) error {
+	var (
+		body   = mig.down
+		mark   = "UPDATE " + migrationsTable + " SET dirty = TRUE WHERE version = $1"
+		unmark = "UPDATE " + migrationsTable + " SET dirty = FALSE WHERE version = $1"
+		finish = "DELETE FROM " + migrationsTable + " WHERE version = $1"
+		args   = []any{mig.Version}
+	)
+	if up {
+		body = mig.up
+		mark = "INSERT INTO " + migrationsTable + " (version, name, dirty) VALUES ($1, $2, TRUE)"
+		unmark = "DELETE FROM " + migrationsTable + " WHERE version = $1"
+		finish = "UPDATE " + migrationsTable + " SET dirty = FALSE, applied_at = CURRENT_TIMESTAMP WHERE version = $1"
+		args = append(args, mig.Name)
+	}
+
+	if _, err := conn.ExecContext(ctx, mark, args...); err != nil {
+		return fmt.Errorf("migration %d: %w", mig.Version, err)
+	}
+
+	err := func() error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, body); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, finish, mig.Version); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		// Транзакция откатилась, схема не изменилась - снимаем пометку.
+		if _, uerr := conn.ExecContext(context.Background(), unmark, mig.Version); uerr != nil {
+			m.log.Error("clearing dirty flag: ", uerr)
+		}
+		return fmt.Errorf("migration %06d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int64]MigrationStatus, error) {
+	rows, err := q.QueryContext(ctx, "SELECT version, name, dirty, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", migrationsTable, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		s := MigrationStatus{Applied: true}
+		if err := rows.Scan(&s.Version, &s.Name, &s.Dirty, &s.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+func checkDirty(applied map[int64]MigrationStatus) error {
+	for _, s := range applied {
+		if s.Dirty {
+			return fmt.Errorf("%w at version %d: fix the schema manually and run migrate force", ErrSchemaDirty, s.Version)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package db_test
+
+import (
+	"context"
+	"slices"
+	"test-task/pkg/config"
+	"test-task/pkg/db"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func newSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.Driver = config.DriverSQLite
+	cfg.Path = ":memory:"
+	gdb, err := db.InitDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return gdb
+}
+
+func TestMigrationsUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	m, err := db.NewMigrator(newSQLite(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if down, err := m.Down(ctx, up); err != nil || down != up {
+		t.Fatalf("Down = %d, %v, want %d", down, err, up)
+	}
+	if again, err := m.Up(ctx); err != nil || again != up {
+		t.Fatalf("second Up = %d, %v, want %d", again, err, up)
+	}
+}
+
+func TestUniqueSongsMigrationKeepsMostCompleteDuplicate(t *testing.T) {
+	ctx := context.Background()
+	gdb := newSQLite(t)
+	m, err := db.NewMigrator(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := `INSERT INTO songs (id, tenant_id, "group", song, text) VALUES (?, ?, ?, ?, ?)`
+	rows := []struct {
+		id                        int
+		tenant, group, song, text string
+	}{
+		{1, "default", "Muse", "Uprising", ""},
+		{2, "default", "Muse", "Uprising", "verse"},
+		{3, "default", "Muse", "Starlight", ""},
+		{4, "default", "Muse", "Starlight", ""},
+		{5, "other", "Muse", "Uprising", ""},
+	}
+	for _, r := range rows {
+		if err := gdb.Exec(insert, r.id, r.tenant, r.group, r.song, r.text).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	if err := gdb.Raw(`SELECT id FROM songs ORDER BY id`).Scan(&ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Остаётся запись с текстом, а из равных - самая ранняя.
+	if !slices.Equal(ids, []int{2, 3, 5}) {
+		t.Errorf("ids = %v, want [2 3 5]", ids)
+	}
+	if err := gdb.Exec(insert, 6, "default", "Muse", "Uprising", "").Error; err == nil {
+		t.Error("inserted a duplicate song after the migration")
+	}
+}
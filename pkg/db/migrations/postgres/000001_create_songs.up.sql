@@ -0,0 +1,9 @@
+-- IF NOT EXISTS: таблица могла быть создана ещё через AutoMigrate.
+CREATE TABLE IF NOT EXISTS songs (
+    id           BIGSERIAL PRIMARY KEY,
+    "group"      VARCHAR(100) NOT NULL,
+    song         VARCHAR(100) NOT NULL,
+    text         TEXT,
+    release_date TIMESTAMPTZ,
+    link         VARCHAR(255)
+);
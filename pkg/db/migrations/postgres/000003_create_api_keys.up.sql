@@ -0,0 +1,12 @@
+-- Ключи доступа к API. Хранится только SHA-256 ключа, по prefix ключ
+-- находят при проверке.
+CREATE TABLE api_keys (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    prefix     VARCHAR(32)  NOT NULL UNIQUE,
+    hash       CHAR(64)     NOT NULL,
+    scopes     VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,4 @@
+-- Кто добавил и кто последним изменил песню: sub пользователя
+-- или apikey:<префикс>. У старых записей автор неизвестен.
+ALTER TABLE songs ADD COLUMN created_by VARCHAR(255);
+ALTER TABLE songs ADD COLUMN updated_by VARCHAR(255);
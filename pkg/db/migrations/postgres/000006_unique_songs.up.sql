@@ -0,0 +1,22 @@
+-- Песня с тем же названием у той же группы в библиотеке арендатора
+-- может быть только одна. Дубликаты, которые успели появиться раньше,
+-- удаляются без возможности восстановить их миграцией down: остаётся
+-- самая полная запись (с текстом, затем со ссылкой и датой выхода),
+-- при равенстве - самая ранняя. Перед миграцией сделайте резервную копию.
+DELETE FROM songs
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (
+            PARTITION BY tenant_id, "group", song
+            ORDER BY COALESCE(text, '') = '',
+                     COALESCE(link, '') = '',
+                     release_date IS NULL OR release_date < '1000-01-01',
+                     id
+        ) AS pos
+        FROM songs
+    ) ranked
+    WHERE pos > 1
+);
+
+DROP INDEX IF EXISTS idx_songs_tenant_group_song;
+CREATE UNIQUE INDEX idx_songs_tenant_group_song ON songs (tenant_id, "group", song);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS songs (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group"      VARCHAR(100) NOT NULL,
+    song         VARCHAR(100) NOT NULL,
+    text         TEXT,
+    release_date DATETIME,
+    link         VARCHAR(255)
+);
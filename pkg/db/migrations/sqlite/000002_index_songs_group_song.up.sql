@@ -0,0 +1,2 @@
+-- Фильтры списка и поиск дубликатов при импорте идут по группе и названию.
+CREATE INDEX IF NOT EXISTS idx_songs_group_song ON songs ("group", song);
@@ -0,0 +1,2 @@
+ALTER TABLE songs DROP COLUMN updated_by;
+ALTER TABLE songs DROP COLUMN created_by;
@@ -0,0 +1,7 @@
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+
+DROP INDEX IF EXISTS idx_songs_tenant_group_song;
+CREATE INDEX IF NOT EXISTS idx_songs_group_song ON songs ("group", song);
+ALTER TABLE songs DROP COLUMN tenant_id;
+
+DROP TABLE IF EXISTS tenants;
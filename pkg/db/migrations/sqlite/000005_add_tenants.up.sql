@@ -0,0 +1,20 @@
+-- Библиотеки разных лейблов в одной установке. Всё, что было создано
+-- до разделения, принадлежит арендатору default.
+CREATE TABLE tenants (
+    id         VARCHAR(64)  PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    max_songs  INTEGER      NOT NULL DEFAULT 0,
+    lyrics_url VARCHAR(255),
+    created_at DATETIME     NOT NULL
+);
+INSERT INTO tenants (id, name, created_at) VALUES ('default', 'Default', CURRENT_TIMESTAMP);
+
+-- SQLite не добавляет столбец с REFERENCES и непустым значением
+-- по умолчанию, поэтому внешнего ключа здесь нет.
+ALTER TABLE songs ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+-- Все выборки песен идут в пределах арендатора.
+DROP INDEX IF EXISTS idx_songs_group_song;
+CREATE INDEX idx_songs_tenant_group_song ON songs (tenant_id, "group", song);
+
+-- NULL - ключ не привязан к арендатору.
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) REFERENCES tenants (id);
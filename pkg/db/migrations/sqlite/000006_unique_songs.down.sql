@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_songs_tenant_group_song;
+CREATE INDEX idx_songs_tenant_group_song ON songs (tenant_id, "group", song);
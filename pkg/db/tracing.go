@@ -0,0 +1,62 @@
+package db
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "tracing:span"
+
+var tracer = otel.Tracer("test-task/pkg/db")
+
+// InstrumentTracing вешает на gorm колбэки, которые открывают span на каждый
+// запрос. Родительский span берётся из контекста, переданного в WithContext.
+func InstrumentTracing(gdb *gorm.DB) error {
+	system := gdb.Dialector.Name()
+	return registerAround(gdb, "tracing", startSpan(system), endSpan)
+}
+
+func startSpan(system string) func(operation string) func(*gorm.DB) {
+	return func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			_, span := tracer.Start(tx.Statement.Context, "gorm."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", system),
+					attribute.String("db.operation", operation),
+				),
+			)
+			tx.InstanceSet(tracingSpanKey, span)
+		}
+	}
+}
+
+func endSpan(string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(tracingSpanKey)
+		if !ok {
+			return
+		}
+		span, ok := v.(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+
+		// Текст запроса без значений параметров: в них могут быть тексты песен.
+		span.SetAttributes(
+			attribute.String("db.sql.table", tableName(tx)),
+			attribute.String("db.statement", tx.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+		)
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+	}
+}
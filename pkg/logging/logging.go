@@ -1,43 +1,22 @@
 package logging
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"os"
 	"path"
 	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
-// writerHook - структура для хука,
-// который перенаправляет логи в несколько потоков
-// (например, в файл и консоль одновременно).
-type writerHook struct {
-	Writer    []io.Writer    // Список мест, куда будут записываться логи
-	LogLevels []logrus.Level // Уровни логирования, которые будут обрабатываться
-}
+// Levels - допустимые уровни журнала, от самого подробного.
+var Levels = []string{"trace", "debug", "info", "warn", "error"}
 
-// Fire вызывается Logrus при создании новой записи лога.
-// Он записывает лог во все указанные Writer.
-func (hook *writerHook) Fire(entry *logrus.Entry) error {
-	line, err := entry.String() // Преобразуем запись в строку
-	if err != nil {
-		return err
-	}
-	// Записываем лог в каждый указанный Writer (файл, консоль и т.д.)
-	for _, w := range hook.Writer {
-		w.Write([]byte(line))
-	}
-	return err
-}
-
-// Levels возвращает список уровней логирования, на которые реагирует хук.
-func (hook *writerHook) Levels() []logrus.Level {
-	return hook.LogLevels
-}
-
-var e *logrus.Entry // Глобальная переменная для логгера
+// e - глобальный логгер. До вызова Setup он пишет текстом в stdout
+// с уровнем info: импорт пакета не создаёт файлов и каталогов.
+var e = newEntry()
 
 // Logger - обертка вокруг logrus.Entry, добавляющая удобные методы.
 type Logger struct {
@@ -54,45 +33,66 @@ func (l *Logger) GetLoggerWithField(k string, v interface{}) Logger {
 	return Logger{l.WithField(k, v)}
 }
 
-// init автоматически вызывается при загрузке пакета и настраивает логгер.
-func init() {
-	l := logrus.New()       // Создаем новый экземпляр Logrus
+func newEntry() *logrus.Entry {
+	l := logrus.New()
 	l.SetReportCaller(true) // Включаем отображение файла и строки вызова
+	l.Formatter = newFormatter(false)
+	l.SetLevel(logrus.InfoLevel)
+	return logrus.NewEntry(l)
+}
 
-	// Настройка форматтера
-	l.Formatter = &logrus.TextFormatter{
-		CallerPrettyfier: func(f *runtime.Frame) (function string, file string) {
-			filename := path.Base(f.File) // Оставляем только имя файла
-			return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
-		},
-		DisableColors: false, // Включаем цветной вывод (если терминал поддерживает)
-		FullTimestamp: true,  // Включаем полные временные метки
-	}
+// callerPrettyfier оставляет от пути к файлу только его имя.
+func callerPrettyfier(f *runtime.Frame) (function string, file string) {
+	filename := path.Base(f.File)
+	return fmt.Sprintf("%s()", f.Function), fmt.Sprintf("%s:%d", filename, f.Line)
+}
 
-	// Создаем папку для логов, если она не существует
-	err := os.MkdirAll("../logs", 0755)
-	if err != nil {
-		panic(err)
+func newFormatter(json bool) logrus.Formatter {
+	if json {
+		return &logrus.JSONFormatter{CallerPrettyfier: callerPrettyfier}
+	}
+	return &logrus.TextFormatter{
+		CallerPrettyfier: callerPrettyfier,
+		FullTimestamp:    true, // Включаем полные временные метки
 	}
+}
 
-	// Открываем файл logs/all.log для записи логов
-	allFile, err := os.OpenFile("../logs/all.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+// SetLevel меняет уровень журнала на лету, в том числе для логгеров,
+// уже полученных через GetLogger и FromContext.
+func SetLevel(level string) error {
+	if !slices.Contains(Levels, level) {
+		return fmt.Errorf("unknown log level %q, use one of %s", level, strings.Join(Levels, ", "))
+	}
+	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	e.Logger.SetLevel(lvl)
+	return nil
+}
 
-	// Отключаем стандартный вывод логов (они будут идти только в хук)
-	l.SetOutput(io.Discard)
+// Level возвращает текущий уровень журнала в том же написании,
+// что и в настройках.
+func Level() string {
+	lvl := e.Logger.GetLevel()
+	if lvl == logrus.WarnLevel {
+		return "warn"
+	}
+	return lvl.String()
+}
 
-	// Добавляем хук, который записывает логи в файл и консоль
-	l.AddHook(&writerHook{
-		Writer:    []io.Writer{allFile, os.Stdout}, // Логи будут в файле и в консоли
-		LogLevels: logrus.AllLevels,                // Логи всех уровней
-	})
+type ctxKey struct{}
 
-	// Устанавливаем минимальный уровень логирования (самый подробный - Trace)
-	l.SetLevel(logrus.TraceLevel)
+// WithContext кладёт логгер в контекст. Так запись о запросе несёт его поля
+// (request_id) через обработчик, сервис и репозиторий.
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
 
-	// Создаем глобальный экземпляр логгера
-	e = logrus.NewEntry(l)
+// FromContext возвращает логгер из контекста или глобальный, если его там нет.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+	return GetLogger()
 }
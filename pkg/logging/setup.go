@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Options - параметры журнала для Setup. Без Stdout и File журнал
+// никуда не пишется.
+type Options struct {
+	Level  string // один из Levels
+	JSON   bool
+	Stdout bool
+	File   string // пусто - в файл не писать
+	// Файл ротируется по размеру и, если задано, по времени. Старые файлы
+	// удаляются по возрасту и по количеству; 0 - без ограничения.
+	MaxSizeMB   int
+	RotateEvery time.Duration
+	MaxAgeDays  int
+	MaxBackups  int
+	Compress    bool
+}
+
+// Setup настраивает глобальный логгер: уровень, формат и назначения.
+// Логгеры, полученные до вызова, тоже начинают писать по-новому.
+// Возвращает функцию, которая останавливает ротацию по времени
+// и закрывает файл журнала; её нужно вызвать перед выходом.
+func Setup(opts Options) (func() error, error) {
+	if err := SetLevel(opts.Level); err != nil {
+		return nil, err
+	}
+
+	var (
+		writers []io.Writer
+		closers []func() error
+	)
+	if opts.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+	if opts.File != "" {
+		file, closeFile, err := openFile(opts)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, file)
+		closers = append(closers, closeFile)
+	}
+
+	l := e.Logger
+	l.Formatter = newFormatter(opts.JSON)
+	switch len(writers) {
+	case 0:
+		l.SetOutput(io.Discard)
+	case 1:
+		l.SetOutput(writers[0])
+	default:
+		l.SetOutput(io.MultiWriter(writers...))
+	}
+
+	return func() error {
+		var err error
+		for _, c := range closers {
+			if cerr := c(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// openFile открывает файл журнала с ротацией. Каталог создаётся здесь,
+// а не при импорте пакета, и только если файл действительно нужен.
+func openFile(opts Options) (io.Writer, func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   opts.File,
+		MaxSize:    opts.MaxSizeMB,
+		MaxAge:     opts.MaxAgeDays,
+		MaxBackups: opts.MaxBackups,
+		Compress:   opts.Compress,
+		LocalTime:  true,
+	}
+	// Lumberjack открывает файл лениво, при первой записи. Проверяем сразу,
+	// чтобы недоступный путь останавливал приложение при старте.
+	if _, err := file.Write(nil); err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	if opts.RotateEvery <= 0 {
+		return file, file.Close, nil
+	}
+
+	// Lumberjack умеет ротировать только по размеру, по времени - по таймеру.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(opts.RotateEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := file.Rotate(); err != nil {
+					fmt.Fprintln(os.Stderr, "failed to rotate log file:", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return file, func() error {
+		close(stop)
+		<-done
+		return file.Close()
+	}, nil
+}
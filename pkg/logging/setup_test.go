@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetupWritesJSONToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	closeLog, err := Setup(Options{Level: "warn", JSON: true, File: path, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := Setup(Options{Level: "info", Stdout: true}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	GetLogger().Info("skipped below the level")
+	GetLogger().Warn("written")
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("log is not a single JSON entry: %v\n%s", err, data)
+	}
+	if entry["msg"] != "written" || entry["level"] != "warning" {
+		t.Errorf("entry = %v, want the warning only", entry)
+	}
+}
+
+func TestSetupRejectsUnknownLevel(t *testing.T) {
+	if _, err := Setup(Options{Level: "verbose"}); err == nil {
+		t.Error("Setup accepted an unknown level")
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// CatalogueCounter считает песни в каталоге: всего и без текста.
+type CatalogueCounter func(ctx context.Context) (total, withoutLyrics int64, err error)
+
+const (
+	// Подсчёт - два COUNT(*) по всей таблице. Опрос /metrics не должен
+	// ждать медленную БД дольше этого, остальные метрики от него не зависят.
+	catalogueTimeout = 5 * time.Second
+	// Между опросами каталог считается не чаще этого: размер библиотеки
+	// меняется медленно, а экземпляров, которые опрашивают, может быть много.
+	catalogueTTL = time.Minute
+)
+
+// catalogueCollector считает песни по БД, а не держит счётчики в памяти:
+// песни могут добавляться и другими экземплярами приложения.
+type catalogueCollector struct {
+	count         CatalogueCounter
+	total         *prometheus.Desc
+	withoutLyrics *prometheus.Desc
+
+	// mu держат и на время подсчёта: одновременные опросы ждут один
+	// подсчёт, а не запускают каждый свой.
+	mu           sync.Mutex
+	countedAt    time.Time
+	lastTotal    int64
+	lastNoLyrics int64
+}
+
+// RegisterCatalogue добавляет в /metrics размер каталога.
+func RegisterCatalogue(count CatalogueCounter) error {
+	return Registry.Register(&catalogueCollector{
+		count:         count,
+		total:         prometheus.NewDesc("catalogue_songs", "Songs in the library.", nil, nil),
+		withoutLyrics: prometheus.NewDesc("catalogue_songs_without_lyrics", "Songs in the library without lyrics.", nil, nil),
+	})
+}
+
+func (c *catalogueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.withoutLyrics
+}
+
+func (c *catalogueCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.countedAt) > catalogueTTL {
+		ctx, cancel := context.WithTimeout(context.Background(), catalogueTimeout)
+		total, withoutLyrics, err := c.count(ctx)
+		cancel()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.total, err)
+			ch <- prometheus.NewInvalidMetric(c.withoutLyrics, err)
+			return
+		}
+		c.countedAt, c.lastTotal, c.lastNoLyrics = time.Now(), total, withoutLyrics
+	}
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(c.lastTotal))
+	ch <- prometheus.MustNewConstMetric(c.withoutLyrics, prometheus.GaugeValue, float64(c.lastNoLyrics))
+}
+
+// RegisterDBStats добавляет в /metrics состояние пула соединений.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCatalogueIsCachedAndBounded(t *testing.T) {
+	calls := 0
+	var deadline time.Time
+	err := RegisterCatalogue(func(ctx context.Context) (int64, int64, error) {
+		calls++
+		deadline, _ = ctx.Deadline()
+		if calls == 1 {
+			return 0, 0, errors.New("database is down")
+		}
+		return 10, 3, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scrape := func() (int, string) {
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, _ := io.ReadAll(w.Body)
+		return w.Code, string(body)
+	}
+
+	// Ошибка подсчёта не должна ронять остальные метрики.
+	code, body := scrape()
+	if code != http.StatusOK || !strings.Contains(body, "go_goroutines") {
+		t.Fatalf("failed catalogue: status %d, other metrics missing", code)
+	}
+	if strings.Contains(body, "catalogue_songs ") {
+		t.Error("catalogue_songs reported although counting failed")
+	}
+	if until := time.Until(deadline); until <= 0 || until > catalogueTimeout {
+		t.Errorf("count deadline in %s, want within %s", until, catalogueTimeout)
+	}
+
+	for range 2 {
+		if _, body = scrape(); !strings.Contains(body, "catalogue_songs 10") || !strings.Contains(body, "catalogue_songs_without_lyrics 3") {
+			t.Fatalf("catalogue metrics missing:\n%s", body)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("counted %d times, want 2: a successful count is reused", calls)
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http"
+	"test-task/pkg/logging"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry - собственный реестр метрик приложения, чтобы в /metrics
+// не попадало то, что сторонние библиотеки регистрируют глобально.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limited_total",
+		Help: "Requests rejected with 429 by route group: read, write, create or bulk.",
+	}, []string{"limit"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Failed database queries by operation and table.",
+	}, []string{"operation", "table"})
+
+	EnrichmentJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "enrichment_jobs_total",
+		Help: "Enrichment jobs by outcome: succeeded, failed, interrupted (by shutdown), dropped (queue full) or rejected (shutting down).",
+	}, []string{"outcome"})
+
+	ExternalAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "external_api_requests_total",
+		Help: "Requests to the song info API by result: ok, client_error, server_error, error, canceled or circuit_open.",
+	}, []string{"result"})
+
+	ExternalAPIDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "external_api_request_duration_seconds",
+		Help:    "Latency of requests to the song info API.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		RateLimited,
+		DBQueryDuration,
+		DBQueryErrors,
+		EnrichmentJobs,
+		ExternalAPIRequests,
+		ExternalAPIDuration,
+	)
+}
+
+// Handler отдаёт метрики в формате Prometheus. Если какой-то сборщик
+// не смог собрать свои метрики, остальные всё равно отдаются, а ошибка
+// попадает в лог и в promhttp_metric_handler_errors_total.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry:      Registry,
+		ErrorHandling: promhttp.ContinueOnError,
+		ErrorLog:      errorLog{},
+	})
+}
+
+// errorLog пишет ошибки сборщиков в лог приложения.
+type errorLog struct{}
+
+func (errorLog) Println(v ...any) {
+	logging.GetLogger().Error(append([]any{"metrics: "}, v...)...)
+}
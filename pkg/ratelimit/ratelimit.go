@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket - ведро токенов: наполняется со скоростью rate в секунду,
+// вмещает не больше burst. Каждый запрос забирает один токен.
+type Bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket создаёт полное ведро.
+func NewBucket(rate float64, burst int) *Bucket {
+	return &Bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Result - итог попытки взять токен.
+type Result struct {
+	Allowed bool
+	// Limit - размер ведра, Remaining - сколько токенов осталось.
+	Limit     int
+	Remaining int
+	// RetryAfter - когда появится следующий токен, Reset - когда ведро
+	// снова заполнится целиком.
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Allow забирает токен, если он есть.
+func (b *Bucket) Allow() Result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     int(b.burst),
+		Remaining: int(b.tokens),
+		Reset:     b.after(b.burst),
+	}
+	if !allowed {
+		result.RetryAfter = b.after(1)
+	}
+	return result
+}
+
+// Wait ждёт токен, пока не отменён ctx.
+func (b *Bucket) Wait(ctx context.Context) error {
+	for {
+		result := b.Allow()
+		if result.Allowed {
+			return nil
+		}
+
+		t := time.NewTimer(result.RetryAfter)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (b *Bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// after - через сколько в ведре будет n токенов.
+func (b *Bucket) after(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// idle - ведро долго не трогали, и оно уже полное: хранить его незачем,
+// новое ведро для того же клиента будет таким же.
+func (b *Bucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// sweepEvery - как часто Limiter выбрасывает полные вёдра.
+const sweepEvery = time.Minute
+
+// Limiter держит по ведру на клиента. Вёдра создаются при первом
+// запросе и удаляются, когда клиент затихает, поэтому память не растёт
+// с числом когда-либо приходивших адресов.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*Bucket
+	lastSweep time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: burst, buckets: map[string]*Bucket{}, lastSweep: time.Now()}
+}
+
+// Allow забирает токен из ведра клиента key.
+func (l *Limiter) Allow(key string) Result {
+	return l.bucket(key).Allow()
+}
+
+func (l *Limiter) bucket(key string) *Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now := time.Now(); now.Sub(l.lastSweep) >= sweepEvery {
+		for k, b := range l.buckets {
+			if b.idle(now) {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+	return b
+}